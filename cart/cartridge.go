@@ -1,36 +1,139 @@
 package cart
 
+import (
+	"sync"
+	"time"
+)
+
 // Cartridge for Game Boy.
-// TODO: Most cartridge functionality.
 type Cartridge struct {
-	data []uint8
+	header   *Header
+	warnings []error
+
+	// Memory.
+	rom []uint8
+	ram []uint8
+
+	// Memory bank controller.
+	mbc mbc
+
+	// Real-time clock, if the cartridge has one.
+	rtc *rtc
+
+	// Rumble motor callback.
+	rumbleHandler RumbleHandler
+
+	// Battery save state. The mutex guards everything that a flush can touch, since flushes can
+	// happen from outside the emulation loop.
+	savePath     string
+	dirty        bool
+	stopFlush    chan bool
+	requestFlush chan bool
+	mutex        sync.Mutex
 }
 
 func NewCartridge(data []uint8) (*Cartridge, error) {
+	header, warnings, err := ParseHeader(data)
+	if err != nil {
+		return nil, err
+	}
+
 	c := &Cartridge{
-		data: data,
+		header:   header,
+		warnings: warnings,
+		rom:      data,
+	}
+
+	// Allocate the external RAM. MBC2 has its RAM built in, and does not declare it in the header.
+	switch {
+	case header.Type == TypeMBC2 || header.Type == TypeMBC2Battery:
+		c.ram = make([]uint8, MBC2RAMSize)
+	case header.Type.HasRAM():
+		c.ram = make([]uint8, header.RAMSize)
+	}
+
+	// Create the real-time clock.
+	if header.Type.HasTimer() {
+		c.rtc = newRTC(time.Now)
+	}
+
+	// Create the memory bank controller.
+	c.mbc, err = newMBC(c)
+	if err != nil {
+		return nil, err
 	}
 
 	return c, nil
 }
 
-// Read a byte from the cartridge ROM.
+// Get the parsed cartridge header.
+func (c *Cartridge) Header() *Header {
+	return c.header
+}
+
+// Get any non-fatal problems found while loading the cartridge, such as checksum mismatches.
+func (c *Cartridge) Warnings() []error {
+	return c.warnings
+}
+
+// Set the time source used by the real-time clock. This is useful for advancing the clock
+// deterministically.
+func (c *Cartridge) SetTimeSource(now TimeSource) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.rtc != nil {
+		c.rtc.update()
+		c.rtc.now = now
+		c.rtc.last = now()
+	}
+}
+
+// Set the handler that is called when the rumble motor is turned on or off. Only rumble
+// cartridges will call it.
+func (c *Cartridge) SetRumbleHandler(handler RumbleHandler) {
+	c.rumbleHandler = handler
+}
+
+// Read a byte from the cartridge ROM. The address is in the range [0x0000, 0x8000).
 func (c *Cartridge) ReadROM(addr uint16) uint8 {
-	return c.data[addr]
+	return c.mbc.ReadROM(addr)
+}
+
+// Get the ROM bank currently mapped at an address. Bank numbers are wrapped around the size of
+// the ROM, the same way they are when reading.
+func (c *Cartridge) ROMBank(addr uint16) int {
+	banks := (len(c.rom) + ROMBankSize - 1) / ROMBankSize
+	if banks == 0 {
+		return 0
+	}
+	return c.mbc.ROMBank(addr) % banks
 }
 
 // Write a byte to the cartridge ROM. This is used for memory banking.
 func (c *Cartridge) WriteROM(addr uint16, v uint8) {
-	// TODO.
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.mbc.WriteROM(addr, v)
 }
 
-// Read a byte from the cartridge RAM.
+// Read a byte from the cartridge RAM. The address is in the range [0x0000, 0x2000).
 func (c *Cartridge) ReadRAM(addr uint16) uint8 {
-	// TODO.
-	return 0x00
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.mbc.ReadRAM(addr)
+}
+
+// Get whether the cartridge RAM is enabled.
+func (c *Cartridge) RAMEnabled() bool {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.mbc.RAMEnabled()
 }
 
 // Write a byte to the cartridge RAM.
 func (c *Cartridge) WriteRAM(addr uint16, v uint8) {
-	// TODO.
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.mbc.WriteRAM(addr, v)
 }
@@ -0,0 +1,254 @@
+package cart
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ruiqimao/go-gb-emu/utils"
+)
+
+// Header layout:
+// 0100 - 0103
+//   Entry point.
+// 0104 - 0133
+//   Nintendo logo.
+// 0134 - 0143
+//   Title. Newer cartridges use 013F - 0142 for the manufacturer code and 0143 for the CGB flag.
+// 0144 - 0145
+//   New licensee code.
+// 0146
+//   SGB flag.
+// 0147
+//   Cartridge type.
+// 0148
+//   ROM size.
+// 0149
+//   RAM size.
+// 014A
+//   Destination code.
+// 014B
+//   Old licensee code.
+// 014C
+//   Mask ROM version number.
+// 014D
+//   Header checksum.
+// 014E - 014F
+//   Global checksum.
+const (
+	AddrHeader           = 0x0100
+	AddrLogo             = 0x0104
+	AddrTitle            = 0x0134
+	AddrManufacturerCode = 0x013f
+	AddrCGBFlag          = 0x0143
+	AddrNewLicensee      = 0x0144
+	AddrSGBFlag          = 0x0146
+	AddrType             = 0x0147
+	AddrROMSize          = 0x0148
+	AddrRAMSize          = 0x0149
+	AddrDestination      = 0x014a
+	AddrOldLicensee      = 0x014b
+	AddrVersion          = 0x014c
+	AddrHeaderChecksum   = 0x014d
+	AddrGlobalChecksum   = 0x014e
+	AddrHeaderEnd        = 0x0150
+)
+
+// CGB flag values.
+const (
+	CGBSupported = 0x80 // Game supports CGB functions, but works on DMG as well.
+	CGBOnly      = 0xc0 // Game works on CGB only.
+)
+
+// SGB flag values.
+const (
+	SGBSupported = 0x03
+)
+
+// Old licensee code indicating that the new licensee code should be used instead.
+const (
+	UseNewLicensee = 0x33
+)
+
+// Destination codes.
+const (
+	DestinationJapan    = 0x00
+	DestinationOverseas = 0x01
+)
+
+// Header is the parsed cartridge header.
+type Header struct {
+	Title            string
+	ManufacturerCode string
+	CGBFlag          uint8
+	SGBFlag          uint8
+	NewLicensee      string
+	OldLicensee      uint8
+	Type             CartridgeType
+	ROMSize          int // ROM size in bytes.
+	RAMSize          int // External RAM size in bytes.
+	Destination      uint8
+	Version          uint8
+	HeaderChecksum   uint8
+	GlobalChecksum   uint16
+}
+
+// A ChecksumError is reported when a checksum in the header does not match the ROM contents.
+type ChecksumError struct {
+	Name     string
+	Expected uint16
+	Actual   uint16
+}
+
+func (e *ChecksumError) Error() string {
+	return fmt.Sprintf("%s checksum mismatch: header has %04x, computed %04x", e.Name, e.Expected, e.Actual)
+}
+
+// Parse the cartridge header from ROM data. Returns the header and any non-fatal warnings.
+func ParseHeader(data []uint8) (*Header, []error, error) {
+	if len(data) < AddrHeaderEnd {
+		return nil, nil, fmt.Errorf("ROM too small to contain a header: %v", len(data))
+	}
+
+	h := &Header{
+		CGBFlag:        data[AddrCGBFlag],
+		SGBFlag:        data[AddrSGBFlag],
+		OldLicensee:    data[AddrOldLicensee],
+		Type:           CartridgeType(data[AddrType]),
+		Destination:    data[AddrDestination],
+		Version:        data[AddrVersion],
+		HeaderChecksum: data[AddrHeaderChecksum],
+		GlobalChecksum: utils.CombineBytes(data[AddrGlobalChecksum], data[AddrGlobalChecksum+1]),
+	}
+
+	// Parse the title. CGB cartridges reserve the last byte of the title for the CGB flag, and
+	// newer ones also reserve the 4 bytes before it for the manufacturer code.
+	titleEnd := AddrCGBFlag + 1
+	if h.CGB() {
+		titleEnd = AddrCGBFlag
+		code := data[AddrManufacturerCode:AddrCGBFlag]
+		if isManufacturerCode(code) {
+			h.ManufacturerCode = string(code)
+			titleEnd = AddrManufacturerCode
+		}
+	}
+	h.Title = parseString(data[AddrTitle:titleEnd])
+
+	// The new licensee code is only used if the old licensee code says so.
+	if h.OldLicensee == UseNewLicensee {
+		h.NewLicensee = parseString(data[AddrNewLicensee : AddrNewLicensee+2])
+	}
+
+	// Decode the ROM and RAM sizes.
+	var ok bool
+	h.ROMSize, ok = romSizes[data[AddrROMSize]]
+	if !ok {
+		return nil, nil, fmt.Errorf("Unknown ROM size code: %02x", data[AddrROMSize])
+	}
+	h.RAMSize, ok = ramSizes[data[AddrRAMSize]]
+	if !ok {
+		return nil, nil, fmt.Errorf("Unknown RAM size code: %02x", data[AddrRAMSize])
+	}
+
+	// Verify the header against the ROM contents. None of these are fatal, as plenty of homebrew
+	// and test ROMs get them wrong.
+	var warnings []error
+	if sum := headerChecksum(data); sum != h.HeaderChecksum {
+		warnings = append(warnings, &ChecksumError{"Header", uint16(h.HeaderChecksum), uint16(sum)})
+	}
+	if sum := globalChecksum(data); sum != h.GlobalChecksum {
+		warnings = append(warnings, &ChecksumError{"Global", h.GlobalChecksum, sum})
+	}
+	if len(data) != h.ROMSize {
+		warnings = append(warnings, fmt.Errorf("ROM size mismatch: header has %v, file has %v", h.ROMSize, len(data)))
+	}
+	if _, ok := cartridgeTypes[h.Type]; !ok {
+		warnings = append(warnings, fmt.Errorf("Unknown cartridge type: %02x", uint8(h.Type)))
+	}
+
+	return h, warnings, nil
+}
+
+// Get whether the cartridge supports CGB functions.
+func (h *Header) CGB() bool {
+	return h.CGBFlag&CGBSupported != 0
+}
+
+// Get whether the cartridge supports SGB functions.
+func (h *Header) SGB() bool {
+	return h.SGBFlag == SGBSupported && h.OldLicensee == UseNewLicensee
+}
+
+// Get the licensee code as a string. Old licensee codes are formatted as hex.
+func (h *Header) Licensee() string {
+	if h.OldLicensee == UseNewLicensee {
+		return h.NewLicensee
+	}
+	return fmt.Sprintf("%02X", h.OldLicensee)
+}
+
+// Compute the header checksum over 0134 - 014C.
+func headerChecksum(data []uint8) uint8 {
+	sum := uint8(0)
+	for _, v := range data[AddrTitle:AddrHeaderChecksum] {
+		sum = sum - v - 1
+	}
+	return sum
+}
+
+// Compute the global checksum over the whole ROM, excluding the global checksum itself.
+func globalChecksum(data []uint8) uint16 {
+	sum := uint16(0)
+	for i, v := range data {
+		if i == AddrGlobalChecksum || i == AddrGlobalChecksum+1 {
+			continue
+		}
+		sum += uint16(v)
+	}
+	return sum
+}
+
+// Parse a NUL-padded ASCII string.
+func parseString(data []uint8) string {
+	s := string(data)
+	if i := strings.IndexByte(s, 0x00); i >= 0 {
+		s = s[:i]
+	}
+	return strings.TrimRight(s, " ")
+}
+
+// Check whether the bytes look like a manufacturer code. Older cartridges use this space for the
+// title, so only treat it as a code if it is made up entirely of uppercase letters and digits.
+func isManufacturerCode(data []uint8) bool {
+	for _, v := range data {
+		if !(v >= 'A' && v <= 'Z') && !(v >= '0' && v <= '9') {
+			return false
+		}
+	}
+	return true
+}
+
+// ROM sizes by header code.
+var romSizes = map[uint8]int{
+	0x00: 0x8000,   // 32 KiB, no banking.
+	0x01: 0x10000,  // 64 KiB, 4 banks.
+	0x02: 0x20000,  // 128 KiB, 8 banks.
+	0x03: 0x40000,  // 256 KiB, 16 banks.
+	0x04: 0x80000,  // 512 KiB, 32 banks.
+	0x05: 0x100000, // 1 MiB, 64 banks.
+	0x06: 0x200000, // 2 MiB, 128 banks.
+	0x07: 0x400000, // 4 MiB, 256 banks.
+	0x08: 0x800000, // 8 MiB, 512 banks.
+	0x52: 0x120000, // 1.125 MiB, 72 banks.
+	0x53: 0x140000, // 1.25 MiB, 80 banks.
+	0x54: 0x180000, // 1.5 MiB, 96 banks.
+}
+
+// RAM sizes by header code.
+var ramSizes = map[uint8]int{
+	0x00: 0,       // None.
+	0x01: 0x800,   // 2 KiB. Unofficial, but used by some homebrew.
+	0x02: 0x2000,  // 8 KiB, 1 bank.
+	0x03: 0x8000,  // 32 KiB, 4 banks.
+	0x04: 0x20000, // 128 KiB, 16 banks.
+	0x05: 0x10000, // 64 KiB, 8 banks.
+}
@@ -0,0 +1,88 @@
+package cart
+
+import (
+	"fmt"
+	"io"
+)
+
+// Bank sizes.
+const (
+	ROMBankSize = 0x4000
+	RAMBankSize = 0x2000
+)
+
+// A memory bank controller maps the cartridge ROM and RAM into the address space.
+// ROM addresses are in the range [0x0000, 0x8000), and RAM addresses are in the range
+// [0x0000, 0x2000).
+type mbc interface {
+	ReadROM(uint16) uint8
+	ReadRAM(uint16) uint8
+
+	WriteROM(uint16, uint8)
+	WriteRAM(uint16, uint8)
+
+	RAMEnabled() bool
+
+	// Get the ROM bank mapped at an address.
+	ROMBank(uint16) int
+
+	// Serialize the controller registers.
+	SaveState(io.Writer) error
+	LoadState(io.Reader) error
+}
+
+// Create the memory bank controller for the cartridge type.
+func newMBC(c *Cartridge) (mbc, error) {
+	switch c.header.Type {
+	case TypeROM, TypeROMRAM, TypeROMRAMBattery:
+		return newROMOnly(c), nil
+	case TypeMBC1, TypeMBC1RAM, TypeMBC1RAMBattery:
+		return newMBC1(c), nil
+	case TypeMBC2, TypeMBC2Battery:
+		return newMBC2(c), nil
+	case TypeMBC3TimerBattery, TypeMBC3TimerRAMBattery, TypeMBC3, TypeMBC3RAM, TypeMBC3RAMBattery:
+		return newMBC3(c), nil
+	case TypeMBC5, TypeMBC5RAM, TypeMBC5RAMBattery, TypeMBC5Rumble, TypeMBC5RumbleRAM, TypeMBC5RumbleRAMBattery:
+		return newMBC5(c), nil
+	}
+	return nil, fmt.Errorf("Unsupported cartridge type: %v (%02x)", c.header.Type, uint8(c.header.Type))
+}
+
+// Read a byte from a ROM bank. Bank numbers wrap around the size of the ROM.
+func (c *Cartridge) readROMBank(bank int, addr uint16) uint8 {
+	if len(c.rom) == 0 {
+		return 0xff
+	}
+	offset := bank*ROMBankSize + int(addr%ROMBankSize)
+	return c.rom[offset%len(c.rom)]
+}
+
+// Read a byte from a RAM bank. Bank numbers wrap around the size of the RAM.
+func (c *Cartridge) readRAMBank(bank int, addr uint16) uint8 {
+	if len(c.ram) == 0 {
+		return 0xff
+	}
+	offset := bank*RAMBankSize + int(addr%RAMBankSize)
+	return c.ram[offset%len(c.ram)]
+}
+
+// Write a byte to a RAM bank. Bank numbers wrap around the size of the RAM.
+func (c *Cartridge) writeRAMBank(bank int, addr uint16, v uint8) {
+	if len(c.ram) == 0 {
+		return
+	}
+	offset := bank*RAMBankSize + int(addr%RAMBankSize)
+	c.ram[offset%len(c.ram)] = v
+	c.dirty = true
+}
+
+// Get the new value of a RAM enable flag after a write to the RAM enable register. Any value with
+// 0xA in the lower 4 bits enables RAM. Disabling RAM requests a flush of the save file, since games
+// usually do so once they are done saving.
+func (c *Cartridge) updateRAMEnable(enabled bool, v uint8) bool {
+	enable := v&0x0f == 0x0a
+	if enabled && !enable {
+		c.ramDisabled()
+	}
+	return enable
+}
@@ -0,0 +1,139 @@
+package cart
+
+import (
+	"bytes"
+)
+
+// MBC1 register ranges.
+const (
+	MBC1RAMEnable = 0x0000 // 0000 - 1FFF: RAM enable.
+	MBC1ROMBank   = 0x2000 // 2000 - 3FFF: Lower 5 bits of the ROM bank number.
+	MBC1UpperBank = 0x4000 // 4000 - 5FFF: RAM bank number, or upper 2 bits of the ROM bank number.
+	MBC1BankMode  = 0x6000 // 6000 - 7FFF: Banking mode select.
+)
+
+// Number of banks between the games in an MBC1M multicart.
+const (
+	MBC1MBankStride = 0x10
+)
+
+// MBC1 memory bank controller.
+// Documented at https://gbdev.io/pandocs/MBC1.html.
+type mbc1 struct {
+	c *Cartridge
+
+	// Registers.
+	ramEnable bool
+	bank1     uint8 // 5-bit ROM bank register.
+	bank2     uint8 // 2-bit upper bank register.
+	mode      bool  // Advanced banking mode.
+
+	// MBC1M multicarts wire the upper bank register one bit lower, and ignore bit 4 of the ROM
+	// bank register.
+	multicart bool
+}
+
+func newMBC1(c *Cartridge) *mbc1 {
+	m := &mbc1{
+		c:         c,
+		bank1:     0x01,
+		multicart: isMBC1M(c.rom),
+	}
+	return m
+}
+
+// Detect an MBC1M multicart. These are 1 MiB cartridges that contain a separate game, each with
+// its own header and Nintendo logo, every 16 banks.
+func isMBC1M(rom []uint8) bool {
+	if len(rom) != 0x100000 {
+		return false
+	}
+	logo := rom[AddrLogo:AddrTitle]
+	offset := MBC1MBankStride * ROMBankSize
+	return bytes.Equal(logo, rom[offset+AddrLogo:offset+AddrTitle])
+}
+
+// Get the number of bits the upper bank register is shifted by in the ROM bank number.
+func (m *mbc1) upperShift() uint {
+	if m.multicart {
+		return 4
+	}
+	return 5
+}
+
+// Get the ROM bank mapped to 0000 - 3FFF.
+func (m *mbc1) lowBank() int {
+	if !m.mode {
+		return 0
+	}
+	return int(m.bank2) << m.upperShift()
+}
+
+// Get the ROM bank mapped to 4000 - 7FFF.
+func (m *mbc1) highBank() int {
+	bank1 := m.bank1
+	if m.multicart {
+		bank1 &= 0x0f
+	}
+	return int(m.bank2)<<m.upperShift() | int(bank1)
+}
+
+// Get the RAM bank mapped to A000 - BFFF.
+func (m *mbc1) ramBank() int {
+	if !m.mode {
+		return 0
+	}
+	return int(m.bank2)
+}
+
+func (m *mbc1) RAMEnabled() bool {
+	return m.ramEnable
+}
+
+func (m *mbc1) ROMBank(addr uint16) int {
+	if addr < ROMBankSize {
+		return m.lowBank()
+	}
+	return m.highBank()
+}
+
+func (m *mbc1) ReadROM(addr uint16) uint8 {
+	return m.c.readROMBank(m.ROMBank(addr), addr)
+}
+
+func (m *mbc1) ReadRAM(addr uint16) uint8 {
+	if !m.ramEnable {
+		return 0xff
+	}
+	return m.c.readRAMBank(m.ramBank(), addr)
+}
+
+func (m *mbc1) WriteROM(addr uint16, v uint8) {
+	switch {
+
+	case addr < MBC1ROMBank:
+		m.ramEnable = m.c.updateRAMEnable(m.ramEnable, v)
+
+	case addr < MBC1UpperBank:
+		// Bank 0 cannot be selected, and is remapped to bank 1. The check is done on all 5 bits,
+		// so banks 0x20, 0x40 and 0x60 cannot be mapped to 4000 - 7FFF either.
+		m.bank1 = v & 0x1f
+		if m.bank1 == 0x00 {
+			m.bank1 = 0x01
+		}
+
+	case addr < MBC1BankMode:
+		m.bank2 = v & 0x03
+
+	default:
+		m.mode = v&0x01 == 0x01
+
+	}
+}
+
+func (m *mbc1) WriteRAM(addr uint16, v uint8) {
+	if !m.ramEnable {
+		return
+	}
+	m.c.writeRAMBank(m.ramBank(), addr, v)
+}
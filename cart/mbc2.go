@@ -0,0 +1,84 @@
+package cart
+
+import (
+	"github.com/ruiqimao/go-gb-emu/utils"
+)
+
+// MBC2 register ranges.
+const (
+	MBC2Register = 0x0000 // 0000 - 3FFF: RAM enable or ROM bank number, depending on address bit 8.
+	MBC2Unused   = 0x4000 // 4000 - 7FFF: Unused.
+)
+
+// MBC2 built-in RAM constants.
+const (
+	MBC2RAMSize    = 0x200 // 512 half-bytes.
+	FlagMBC2ROMSel = 8     // Address bit that selects the ROM bank register.
+)
+
+// MBC2 memory bank controller. It has 512x4 bits of RAM built in, which is stored as one byte per
+// half-byte with the upper 4 bits clear.
+// Documented at https://gbdev.io/pandocs/MBC2.html.
+type mbc2 struct {
+	c *Cartridge
+
+	// Registers.
+	ramEnable bool
+	romBank   uint8
+}
+
+func newMBC2(c *Cartridge) *mbc2 {
+	m := &mbc2{
+		c:       c,
+		romBank: 0x01,
+	}
+	return m
+}
+
+func (m *mbc2) RAMEnabled() bool {
+	return m.ramEnable
+}
+
+func (m *mbc2) ROMBank(addr uint16) int {
+	if addr < ROMBankSize {
+		return 0
+	}
+	return int(m.romBank)
+}
+
+func (m *mbc2) ReadROM(addr uint16) uint8 {
+	return m.c.readROMBank(m.ROMBank(addr), addr)
+}
+
+func (m *mbc2) ReadRAM(addr uint16) uint8 {
+	if !m.ramEnable {
+		return 0xff
+	}
+	// Only the lower 9 bits of the address are used, so the RAM is mirrored across A000 - BFFF.
+	// The upper 4 bits are not connected and read as 1.
+	return m.c.ram[addr%MBC2RAMSize] | 0xf0
+}
+
+func (m *mbc2) WriteROM(addr uint16, v uint8) {
+	if addr >= MBC2Unused {
+		return
+	}
+
+	if utils.GetBit16(addr, FlagMBC2ROMSel) {
+		// Bank 0 is remapped to bank 1.
+		m.romBank = v & 0x0f
+		if m.romBank == 0x00 {
+			m.romBank = 0x01
+		}
+	} else {
+		m.ramEnable = m.c.updateRAMEnable(m.ramEnable, v)
+	}
+}
+
+func (m *mbc2) WriteRAM(addr uint16, v uint8) {
+	if !m.ramEnable {
+		return
+	}
+	m.c.ram[addr%MBC2RAMSize] = v & 0x0f
+	m.c.dirty = true
+}
@@ -0,0 +1,105 @@
+package cart
+
+// MBC3 register ranges.
+const (
+	MBC3RAMEnable = 0x0000 // 0000 - 1FFF: RAM and RTC enable.
+	MBC3ROMBank   = 0x2000 // 2000 - 3FFF: ROM bank number.
+	MBC3RAMBank   = 0x4000 // 4000 - 5FFF: RAM bank number or RTC register select.
+	MBC3Latch     = 0x6000 // 6000 - 7FFF: Latch clock data.
+)
+
+// MBC3 memory bank controller.
+// Documented at https://gbdev.io/pandocs/MBC3.html.
+type mbc3 struct {
+	c *Cartridge
+
+	// Registers.
+	ramEnable bool
+	romBank   uint8
+	ramBank   uint8 // RAM bank 0x00 - 0x07, or RTC register 0x08 - 0x0C.
+
+	// Last value written to the latch register. Latching happens on a write of 0x00 then 0x01.
+	latch uint8
+}
+
+func newMBC3(c *Cartridge) *mbc3 {
+	m := &mbc3{
+		c:       c,
+		romBank: 0x01,
+		latch:   0xff,
+	}
+	return m
+}
+
+// Get whether an RTC register is currently selected.
+func (m *mbc3) rtcSelected() bool {
+	return m.c.rtc != nil && m.ramBank >= RTCSeconds && m.ramBank <= RTCDayHigh
+}
+
+func (m *mbc3) RAMEnabled() bool {
+	return m.ramEnable
+}
+
+func (m *mbc3) ROMBank(addr uint16) int {
+	if addr < ROMBankSize {
+		return 0
+	}
+	return int(m.romBank)
+}
+
+func (m *mbc3) ReadROM(addr uint16) uint8 {
+	return m.c.readROMBank(m.ROMBank(addr), addr)
+}
+
+func (m *mbc3) ReadRAM(addr uint16) uint8 {
+	if !m.ramEnable {
+		return 0xff
+	}
+	if m.rtcSelected() {
+		return m.c.rtc.Read(m.ramBank)
+	}
+	if m.ramBank > 0x07 {
+		return 0xff
+	}
+	return m.c.readRAMBank(int(m.ramBank), addr)
+}
+
+func (m *mbc3) WriteROM(addr uint16, v uint8) {
+	switch {
+
+	case addr < MBC3ROMBank:
+		m.ramEnable = m.c.updateRAMEnable(m.ramEnable, v)
+
+	case addr < MBC3RAMBank:
+		// Bank 0 is remapped to bank 1.
+		m.romBank = v & 0x7f
+		if m.romBank == 0x00 {
+			m.romBank = 0x01
+		}
+
+	case addr < MBC3Latch:
+		m.ramBank = v & 0x0f
+
+	default:
+		if m.latch == 0x00 && v == 0x01 && m.c.rtc != nil {
+			m.c.rtc.latch()
+		}
+		m.latch = v
+
+	}
+}
+
+func (m *mbc3) WriteRAM(addr uint16, v uint8) {
+	if !m.ramEnable {
+		return
+	}
+	if m.rtcSelected() {
+		m.c.rtc.Write(m.ramBank, v)
+		m.c.dirty = true
+		return
+	}
+	if m.ramBank > 0x07 {
+		return
+	}
+	m.c.writeRAMBank(int(m.ramBank), addr, v)
+}
@@ -0,0 +1,109 @@
+package cart
+
+import (
+	"github.com/ruiqimao/go-gb-emu/utils"
+)
+
+// MBC5 register ranges.
+const (
+	MBC5RAMEnable  = 0x0000 // 0000 - 1FFF: RAM enable.
+	MBC5ROMBankLow = 0x2000 // 2000 - 2FFF: Lower 8 bits of the ROM bank number.
+	MBC5ROMBankHi  = 0x3000 // 3000 - 3FFF: Bit 8 of the ROM bank number.
+	MBC5RAMBank    = 0x4000 // 4000 - 5FFF: RAM bank number.
+	MBC5Unused     = 0x6000 // 6000 - 7FFF: Unused.
+)
+
+// Bit of the RAM bank register that controls the rumble motor on rumble cartridges.
+const (
+	FlagRumble = 3
+)
+
+// RumbleHandler is called whenever the rumble motor is turned on or off.
+type RumbleHandler func(on bool)
+
+// MBC5 memory bank controller.
+// Documented at https://gbdev.io/pandocs/MBC5.html.
+type mbc5 struct {
+	c *Cartridge
+
+	// Registers.
+	ramEnable bool
+	romBank   uint16 // 9-bit ROM bank number.
+	ramBank   uint8  // 4-bit RAM bank number.
+
+	// Rumble motor state.
+	rumble bool
+}
+
+func newMBC5(c *Cartridge) *mbc5 {
+	m := &mbc5{
+		c:       c,
+		romBank: 0x001,
+	}
+	return m
+}
+
+func (m *mbc5) RAMEnabled() bool {
+	return m.ramEnable
+}
+
+func (m *mbc5) ROMBank(addr uint16) int {
+	if addr < ROMBankSize {
+		return 0
+	}
+	// Unlike other controllers, bank 0 can be mapped to 4000 - 7FFF.
+	return int(m.romBank)
+}
+
+func (m *mbc5) ReadROM(addr uint16) uint8 {
+	return m.c.readROMBank(m.ROMBank(addr), addr)
+}
+
+func (m *mbc5) ReadRAM(addr uint16) uint8 {
+	if !m.ramEnable {
+		return 0xff
+	}
+	return m.c.readRAMBank(int(m.ramBank), addr)
+}
+
+func (m *mbc5) WriteROM(addr uint16, v uint8) {
+	switch {
+
+	case addr < MBC5ROMBankLow:
+		m.ramEnable = m.c.updateRAMEnable(m.ramEnable, v)
+
+	case addr < MBC5ROMBankHi:
+		m.romBank = m.romBank&0x100 | uint16(v)
+
+	case addr < MBC5RAMBank:
+		m.romBank = m.romBank&0x0ff | uint16(v&0x01)<<8
+
+	case addr < MBC5Unused:
+		if m.c.header.Type.HasRumble() {
+			// Rumble cartridges wire bit 3 to the motor instead of the RAM bank.
+			m.ramBank = v & 0x07
+			m.setRumble(utils.GetBit(v, FlagRumble))
+		} else {
+			m.ramBank = v & 0x0f
+		}
+
+	}
+}
+
+func (m *mbc5) WriteRAM(addr uint16, v uint8) {
+	if !m.ramEnable {
+		return
+	}
+	m.c.writeRAMBank(int(m.ramBank), addr, v)
+}
+
+// Turn the rumble motor on or off, notifying the handler on changes.
+func (m *mbc5) setRumble(on bool) {
+	if on == m.rumble {
+		return
+	}
+	m.rumble = on
+	if m.c.rumbleHandler != nil {
+		m.c.rumbleHandler(on)
+	}
+}
@@ -0,0 +1,37 @@
+package cart
+
+// Cartridge without a memory bank controller. The ROM is mapped directly, and any RAM is always
+// enabled.
+type romOnly struct {
+	c *Cartridge
+}
+
+func newROMOnly(c *Cartridge) *romOnly {
+	return &romOnly{
+		c: c,
+	}
+}
+
+func (m *romOnly) RAMEnabled() bool {
+	return len(m.c.ram) > 0
+}
+
+func (m *romOnly) ROMBank(addr uint16) int {
+	return int(addr / ROMBankSize)
+}
+
+func (m *romOnly) ReadROM(addr uint16) uint8 {
+	return m.c.readROMBank(m.ROMBank(addr), addr)
+}
+
+func (m *romOnly) ReadRAM(addr uint16) uint8 {
+	return m.c.readRAMBank(0, addr)
+}
+
+func (m *romOnly) WriteROM(addr uint16, v uint8) {
+	// No banking.
+}
+
+func (m *romOnly) WriteRAM(addr uint16, v uint8) {
+	m.c.writeRAMBank(0, addr, v)
+}
@@ -0,0 +1,182 @@
+package cart
+
+import (
+	"encoding/binary"
+	"fmt"
+	"time"
+
+	"github.com/ruiqimao/go-gb-emu/utils"
+)
+
+// RTC registers, as selected through the MBC3 RAM bank register.
+const (
+	RTCSeconds = 0x08
+	RTCMinutes = 0x09
+	RTCHours   = 0x0a
+	RTCDayLow  = 0x0b
+	RTCDayHigh = 0x0c
+)
+
+// RTC day high register flags.
+const (
+	FlagDayHigh  = 0
+	FlagRTCHalt  = 6
+	FlagDayCarry = 7
+)
+
+// RTC save trailer sizes. The trailer is appended to the battery RAM in the format used by BGB and
+// VBA-M: the live and latched registers as 5 little-endian 32-bit words each, followed by a
+// little-endian UNIX timestamp. Older saves use a 32-bit timestamp instead of a 64-bit one.
+const (
+	RTCTrailerSize    = 48
+	RTCTrailerSizeOld = 44
+)
+
+// TimeSource provides the current time to the RTC.
+type TimeSource func() time.Time
+
+// Real-time clock found on MBC3 cartridges. The registers are only brought up to date when they
+// are accessed, using the time elapsed since the last access.
+type rtc struct {
+	now TimeSource
+
+	// Live registers.
+	seconds uint8
+	minutes uint8
+	hours   uint8
+	days    uint16
+	halt    bool
+	carry   bool
+
+	// Latched registers, in register order.
+	latched [5]uint8
+
+	// Time that the live registers were last brought up to date. Only whole seconds are consumed,
+	// so the sub-second remainder is kept.
+	last time.Time
+}
+
+func newRTC(now TimeSource) *rtc {
+	r := &rtc{
+		now:  now,
+		last: now(),
+	}
+	return r
+}
+
+// Bring the live registers up to date.
+func (r *rtc) update() {
+	now := r.now()
+	if r.halt {
+		r.last = now
+		return
+	}
+
+	// Consume whole elapsed seconds.
+	elapsed := now.Sub(r.last) / time.Second
+	if elapsed <= 0 {
+		return
+	}
+	r.last = r.last.Add(elapsed * time.Second)
+	r.advance(uint64(elapsed))
+}
+
+// Advance the live registers by a number of seconds.
+func (r *rtc) advance(n uint64) {
+	total := uint64(r.seconds) + n
+	r.seconds = uint8(total % 60)
+	total = uint64(r.minutes) + total/60
+	r.minutes = uint8(total % 60)
+	total = uint64(r.hours) + total/60
+	r.hours = uint8(total % 24)
+	total = uint64(r.days) + total/24
+
+	// The day counter is 9 bits wide, and sets the carry flag when it overflows.
+	if total > 0x1ff {
+		r.carry = true
+	}
+	r.days = uint16(total % 0x200)
+}
+
+// Get the live registers, in register order.
+func (r *rtc) registers() [5]uint8 {
+	dh := uint8(r.days>>8) & 0x1
+	dh = utils.SetBit(dh, FlagRTCHalt, r.halt)
+	dh = utils.SetBit(dh, FlagDayCarry, r.carry)
+	return [5]uint8{r.seconds, r.minutes, r.hours, uint8(r.days), dh}
+}
+
+// Set the live registers from values in register order.
+func (r *rtc) setRegisters(regs [5]uint8) {
+	r.seconds = regs[0] & 0x3f
+	r.minutes = regs[1] & 0x3f
+	r.hours = regs[2] & 0x1f
+	r.days = uint16(regs[3]) | uint16(regs[4]&0x1)<<8
+	r.halt = utils.GetBit(regs[4], FlagRTCHalt)
+	r.carry = utils.GetBit(regs[4], FlagDayCarry)
+}
+
+// Latch the live registers so that they can be read.
+func (r *rtc) latch() {
+	r.update()
+	r.latched = r.registers()
+}
+
+// Read a latched register.
+func (r *rtc) Read(reg uint8) uint8 {
+	return r.latched[reg-RTCSeconds]
+}
+
+// Write to a live register.
+func (r *rtc) Write(reg uint8, v uint8) {
+	r.update()
+	regs := r.registers()
+	regs[reg-RTCSeconds] = v
+	r.setRegisters(regs)
+
+	// Writing to the seconds register resets the sub-second counter.
+	if reg == RTCSeconds {
+		r.last = r.now()
+	}
+}
+
+// Serialize the RTC into a save trailer.
+func (r *rtc) trailer() []uint8 {
+	r.update()
+	data := make([]uint8, RTCTrailerSize)
+	for i, v := range r.registers() {
+		binary.LittleEndian.PutUint32(data[i*4:], uint32(v))
+	}
+	for i, v := range r.latched {
+		binary.LittleEndian.PutUint32(data[20+i*4:], uint32(v))
+	}
+	binary.LittleEndian.PutUint64(data[40:], uint64(r.last.Unix()))
+	return data
+}
+
+// Restore the RTC from a save trailer. Time that has passed since the save was written is added
+// to the clock the next time it is accessed.
+func (r *rtc) loadTrailer(data []uint8) error {
+	if len(data) != RTCTrailerSize && len(data) != RTCTrailerSizeOld {
+		return fmt.Errorf("Improper RTC trailer size: %v", len(data))
+	}
+
+	var regs [5]uint8
+	for i := range regs {
+		regs[i] = uint8(binary.LittleEndian.Uint32(data[i*4:]))
+	}
+	r.setRegisters(regs)
+	for i := range r.latched {
+		r.latched[i] = uint8(binary.LittleEndian.Uint32(data[20+i*4:]))
+	}
+
+	var timestamp int64
+	if len(data) == RTCTrailerSize {
+		timestamp = int64(binary.LittleEndian.Uint64(data[40:]))
+	} else {
+		timestamp = int64(binary.LittleEndian.Uint32(data[40:]))
+	}
+	r.last = time.Unix(timestamp, 0)
+
+	return nil
+}
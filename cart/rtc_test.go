@@ -0,0 +1,179 @@
+package cart
+
+import (
+	"testing"
+	"time"
+)
+
+// A clock that only moves when it is told to.
+type fakeClock struct {
+	t time.Time
+}
+
+func (f *fakeClock) now() time.Time {
+	return f.t
+}
+
+func (f *fakeClock) advance(d time.Duration) {
+	f.t = f.t.Add(d)
+}
+
+// Make a 32 KiB cartridge of a type with a RAM size code.
+func newTestCartridge(t *testing.T, typ CartridgeType, ramSize uint8) *Cartridge {
+	rom := make([]uint8, 0x8000)
+	rom[AddrType] = uint8(typ)
+	rom[AddrRAMSize] = ramSize
+	c, err := NewCartridge(rom)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+// Make an MBC3 cartridge with a real-time clock driven by a fake clock, with RAM enabled.
+func newRTCCartridge(t *testing.T) (*Cartridge, *fakeClock) {
+	clock := &fakeClock{time.Unix(1000000, 0)}
+	c := newTestCartridge(t, TypeMBC3TimerRAMBattery, 0x02)
+	c.SetTimeSource(clock.now)
+	c.WriteROM(MBC3RAMEnable, 0x0a)
+	return c, clock
+}
+
+// Latch the clock and read its registers.
+func latchRTC(c *Cartridge) [5]uint8 {
+	c.WriteROM(MBC3Latch, 0x00)
+	c.WriteROM(MBC3Latch, 0x01)
+	return readRTC(c)
+}
+
+// Read the latched registers.
+func readRTC(c *Cartridge) [5]uint8 {
+	var regs [5]uint8
+	for i := range regs {
+		c.WriteROM(MBC3RAMBank, RTCSeconds+uint8(i))
+		regs[i] = c.ReadRAM(0)
+	}
+	return regs
+}
+
+// Write the live registers.
+func writeRTC(c *Cartridge, regs [5]uint8) {
+	for i, v := range regs {
+		c.WriteROM(MBC3RAMBank, RTCSeconds+uint8(i))
+		c.WriteRAM(0, v)
+	}
+}
+
+func TestRTCLatch(t *testing.T) {
+	c, clock := newRTCCartridge(t)
+	writeRTC(c, [5]uint8{10, 20, 3, 0, 0})
+
+	clock.advance(61*time.Second + 500*time.Millisecond)
+	if regs := readRTC(c); regs != [5]uint8{} {
+		t.Errorf("Registers changed before latching: %v", regs)
+	}
+	if regs := latchRTC(c); regs != [5]uint8{11, 21, 3, 0, 0} {
+		t.Errorf("Wrong latched registers: %v", regs)
+	}
+
+	// The latched registers hold still until the next latch, and the half second carries over.
+	clock.advance(500 * time.Millisecond)
+	if regs := readRTC(c); regs != [5]uint8{11, 21, 3, 0, 0} {
+		t.Errorf("Latched registers changed: %v", regs)
+	}
+	if regs := latchRTC(c); regs != [5]uint8{12, 21, 3, 0, 0} {
+		t.Errorf("Wrong latched registers after relatch: %v", regs)
+	}
+
+	// Writing 0x01 again without writing 0x00 first does not latch.
+	clock.advance(time.Second)
+	c.WriteROM(MBC3Latch, 0x01)
+	if regs := readRTC(c); regs[0] != 12 {
+		t.Errorf("Latched without a 0x00 write: %v", regs)
+	}
+}
+
+func TestRTCHalt(t *testing.T) {
+	c, clock := newRTCCartridge(t)
+	writeRTC(c, [5]uint8{0, 0, 0, 0, 1 << FlagRTCHalt})
+
+	clock.advance(time.Hour)
+	if regs := latchRTC(c); regs != [5]uint8{0, 0, 0, 0, 1 << FlagRTCHalt} {
+		t.Errorf("Clock ran while halted: %v", regs)
+	}
+
+	// Time spent halted is not counted once the clock is started again.
+	c.WriteROM(MBC3RAMBank, RTCDayHigh)
+	c.WriteRAM(0, 0x00)
+	clock.advance(5 * time.Second)
+	if regs := latchRTC(c); regs != [5]uint8{5, 0, 0, 0, 0} {
+		t.Errorf("Wrong registers after resuming: %v", regs)
+	}
+}
+
+func TestRTCDayCarry(t *testing.T) {
+	c, clock := newRTCCartridge(t)
+	writeRTC(c, [5]uint8{59, 59, 23, 0xfe, 0x01})
+
+	// Day 0x1ff is the last day before the counter overflows.
+	clock.advance(time.Second)
+	if regs := latchRTC(c); regs != [5]uint8{0, 0, 0, 0xff, 0x01} {
+		t.Errorf("Wrong registers on the last day: %v", regs)
+	}
+	clock.advance(24 * time.Hour)
+	if regs := latchRTC(c); regs != [5]uint8{0, 0, 0, 0x00, 1 << FlagDayCarry} {
+		t.Errorf("Wrong registers after overflow: %v", regs)
+	}
+
+	// The carry flag stays set until it is cleared.
+	clock.advance(24 * time.Hour)
+	if regs := latchRTC(c); regs != [5]uint8{0, 0, 0, 0x01, 1 << FlagDayCarry} {
+		t.Errorf("Carry flag cleared: %v", regs)
+	}
+	c.WriteROM(MBC3RAMBank, RTCDayHigh)
+	c.WriteRAM(0, 0x00)
+	if regs := latchRTC(c); regs[4] != 0x00 {
+		t.Errorf("Carry flag not cleared: %v", regs)
+	}
+}
+
+func TestRTCTrailer(t *testing.T) {
+	c, clock := newRTCCartridge(t)
+	writeRTC(c, [5]uint8{1, 2, 3, 4, 0x01})
+	c.WriteROM(MBC3RAMBank, 0x00)
+	c.WriteRAM(0x10, 0x42)
+	latched := latchRTC(c)
+
+	data := c.SaveData()
+	if len(data) != 0x2000+RTCTrailerSize {
+		t.Fatalf("Wrong save data size: %v", len(data))
+	}
+
+	// Load the save into a new cartridge 100 seconds later.
+	c2, clock2 := newRTCCartridge(t)
+	clock2.t = clock.t.Add(100 * time.Second)
+	err := c2.LoadSaveData(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c2.WriteROM(MBC3RAMBank, 0x00)
+	if v := c2.ReadRAM(0x10); v != 0x42 {
+		t.Errorf("Wrong RAM after load: %02x", v)
+	}
+	if regs := readRTC(c2); regs != latched {
+		t.Errorf("Wrong latched registers after load: %v, expected %v", regs, latched)
+	}
+	if regs := latchRTC(c2); regs != [5]uint8{41, 3, 3, 4, 0x01} {
+		t.Errorf("Time since the save was not added: %v", regs)
+	}
+
+	// Saving again gives the same trailer format.
+	if data := c2.SaveData(); len(data) != 0x2000+RTCTrailerSize {
+		t.Errorf("Wrong save data size after load: %v", len(data))
+	}
+
+	// Bad trailer sizes are rejected.
+	if err := c2.LoadSaveData(data[:len(data)-1]); err == nil {
+		t.Error("Loaded a truncated trailer")
+	}
+}
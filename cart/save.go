@@ -0,0 +1,208 @@
+package cart
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Extension of battery save files.
+const (
+	SaveExtension = ".sav"
+)
+
+// Get the path of the save file that goes with a ROM file. This is the ROM path with its extension
+// replaced by .sav. If the ROM itself ends in .sav, .sav is appended instead so that saving never
+// overwrites the ROM. The case is ignored, since some file systems ignore it too.
+func SavePath(romPath string) string {
+	path := strings.TrimSuffix(romPath, filepath.Ext(romPath)) + SaveExtension
+	if strings.EqualFold(path, romPath) {
+		path = romPath + SaveExtension
+	}
+	return path
+}
+
+// Get the contents of the external RAM. The returned slice is a copy.
+func (c *Cartridge) RAM() []uint8 {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	ram := make([]uint8, len(c.ram))
+	copy(ram, c.ram)
+	return ram
+}
+
+// Get the battery-backed data of the cartridge. This is the raw contents of the external RAM,
+// followed by the RTC trailer if the cartridge has a real-time clock.
+func (c *Cartridge) SaveData() []uint8 {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.saveData()
+}
+
+func (c *Cartridge) saveData() []uint8 {
+	data := make([]uint8, len(c.ram))
+	copy(data, c.ram)
+	if c.rtc != nil {
+		data = append(data, c.rtc.trailer()...)
+	}
+	return data
+}
+
+// Load battery-backed data into the cartridge. The data is in the same format as SaveData, but
+// the RTC trailer may be missing.
+func (c *Cartridge) LoadSaveData(data []uint8) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if len(data) < len(c.ram) {
+		return fmt.Errorf("Save data too small: expected %v, got %v", len(c.ram), len(data))
+	}
+	trailer := data[len(c.ram):]
+
+	// Restore the RTC if there is a trailer for it.
+	if len(trailer) > 0 {
+		if c.rtc == nil {
+			return fmt.Errorf("Save data too large: expected %v, got %v", len(c.ram), len(data))
+		}
+		err := c.rtc.loadTrailer(trailer)
+		if err != nil {
+			return err
+		}
+	}
+
+	copy(c.ram, data)
+
+	// MBC2 RAM only stores the lower 4 bits of each byte.
+	if _, ok := c.mbc.(*mbc2); ok {
+		for i := range c.ram {
+			c.ram[i] &= 0x0f
+		}
+	}
+
+	c.dirty = false
+	return nil
+}
+
+// Attach a save file to a battery-backed cartridge. If the file exists, it is loaded into the
+// cartridge. The battery-backed data is written back to the file whenever it is flushed. Does
+// nothing if the cartridge has no battery.
+func (c *Cartridge) AttachSaveFile(path string) error {
+	if !c.header.Type.HasBattery() {
+		return nil
+	}
+
+	// Load the existing save, if there is one.
+	data, err := ioutil.ReadFile(path)
+	switch {
+	case err == nil:
+		err = c.LoadSaveData(data)
+		if err != nil {
+			return err
+		}
+	case !os.IsNotExist(err):
+		return err
+	}
+
+	c.mutex.Lock()
+	c.savePath = path
+	c.mutex.Unlock()
+	return nil
+}
+
+// Write the battery-backed data to the save file if it has changed since the last flush.
+func (c *Cartridge) Flush() error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.flush()
+}
+
+func (c *Cartridge) flush() error {
+	// RTC cartridges are always written, so that the timestamp stays current.
+	if c.savePath == "" || (!c.dirty && c.rtc == nil) {
+		return nil
+	}
+
+	// Write to a temporary file first so that a failed write does not corrupt the old save.
+	tmpPath := c.savePath + ".tmp"
+	err := ioutil.WriteFile(tmpPath, c.saveData(), 0644)
+	if err != nil {
+		return err
+	}
+	err = os.Rename(tmpPath, c.savePath)
+	if err != nil {
+		return err
+	}
+
+	c.dirty = false
+	return nil
+}
+
+// Start flushing the save file periodically, and soon after the game disables RAM. Errors are sent
+// to the returned channel, which is closed once Close is called.
+func (c *Cartridge) StartAutoFlush(interval time.Duration) <-chan error {
+	errs := make(chan error, 1)
+	c.mutex.Lock()
+	c.stopFlush = make(chan bool)
+	c.requestFlush = make(chan bool, 1)
+	stop := c.stopFlush
+	request := c.requestFlush
+	c.mutex.Unlock()
+
+	flush := func() {
+		if err := c.Flush(); err != nil {
+			// Drop the error if the last one has not been handled yet.
+			select {
+			case errs <- err:
+			default:
+			}
+		}
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		defer close(errs)
+		for {
+			select {
+			case <-ticker.C:
+				flush()
+			case <-request:
+				flush()
+			case <-stop:
+				return
+			}
+		}
+	}()
+
+	return errs
+}
+
+// Stop any periodic flushing and flush the save file one last time.
+func (c *Cartridge) Close() error {
+	c.mutex.Lock()
+	if c.stopFlush != nil {
+		close(c.stopFlush)
+		c.stopFlush = nil
+		c.requestFlush = nil
+	}
+	c.mutex.Unlock()
+
+	return c.Flush()
+}
+
+// Called by the memory bank controller when the game disables RAM. Games toggle RAM often, so the
+// save file is not written here. Instead, the auto-flush goroutine is asked to flush it if it is
+// running, and otherwise it is written on Close.
+func (c *Cartridge) ramDisabled() {
+	if !c.dirty || c.requestFlush == nil {
+		return
+	}
+	select {
+	case c.requestFlush <- true:
+	default:
+	}
+}
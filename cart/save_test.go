@@ -0,0 +1,116 @@
+package cart
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSavePath(t *testing.T) {
+	cases := map[string]string{
+		"game.gb":          "game.sav",
+		"dir/game.gbc":     "dir/game.sav",
+		"dir.v2/game":      "dir.v2/game.sav",
+		"dir/game.v2.gb":   "dir/game.v2.sav",
+		"dir.v2/game.sgb":  "dir.v2/game.sav",
+		"dir/game.gb.sav":  "dir/game.gb.sav.sav",
+		"dir/.hidden.gb":   "dir/.hidden.sav",
+		"dir.v2/game.sav":  "dir.v2/game.sav.sav",
+		"dir/GAME.SAV":     "dir/GAME.SAV.sav",
+		"dir/game.gb.orig": "dir/game.gb.sav",
+	}
+	for rom, want := range cases {
+		if got := SavePath(filepath.FromSlash(rom)); got != filepath.FromSlash(want) {
+			t.Errorf("SavePath(%q) = %q, expected %q", rom, got, want)
+		}
+	}
+}
+
+func TestSaveFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "save")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "game.sav")
+
+	c := newTestCartridge(t, TypeMBC1RAMBattery, 0x02)
+	err = c.AttachSaveFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.WriteROM(MBC1RAMEnable, 0x0a)
+	c.WriteRAM(0x0001, 0x42)
+
+	// Disabling RAM does not write the file without the auto-flush goroutine.
+	c.WriteROM(MBC1RAMEnable, 0x00)
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatalf("Save file written on RAM disable: %v", err)
+	}
+
+	// Closing the cartridge writes the raw RAM.
+	err = c.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(data) != 0x2000 || data[0x0001] != 0x42 {
+		t.Fatalf("Wrong save file: %v bytes, %02x", len(data), data[0x0001])
+	}
+
+	// A new cartridge loads the save.
+	c = newTestCartridge(t, TypeMBC1RAMBattery, 0x02)
+	err = c.AttachSaveFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(c.RAM(), data) {
+		t.Fatal("Save file not loaded")
+	}
+}
+
+func TestSaveFileRAMDisable(t *testing.T) {
+	dir, err := ioutil.TempDir("", "save")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "game.sav")
+
+	c := newTestCartridge(t, TypeMBC1RAMBattery, 0x02)
+	err = c.AttachSaveFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	errs := c.StartAutoFlush(time.Hour)
+
+	// Disabling RAM asks the auto-flush goroutine to write the file.
+	c.WriteROM(MBC1RAMEnable, 0x0a)
+	c.WriteRAM(0x0002, 0x24)
+	c.WriteROM(MBC1RAMEnable, 0x00)
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		data, err := ioutil.ReadFile(path)
+		if err == nil && data[0x0002] == 0x24 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("Save file not flushed after RAM disable")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	err = c.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for err := range errs {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,201 @@
+package cart
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"time"
+)
+
+// Serialized cartridge identity. This is checked when loading to make sure the state belongs to
+// the same cartridge.
+type cartridgeState struct {
+	Type           uint8
+	HeaderChecksum uint8
+	GlobalChecksum uint16
+	RAMSize        uint32
+}
+
+// Serialized RTC state.
+type rtcState struct {
+	Registers [5]uint8
+	Latched   [5]uint8
+	Last      int64 // UNIX time in nanoseconds.
+}
+
+// Serialized memory bank controller states.
+type mbc1State struct {
+	RAMEnable bool
+	Bank1     uint8
+	Bank2     uint8
+	Mode      bool
+}
+
+type mbc2State struct {
+	RAMEnable bool
+	ROMBank   uint8
+}
+
+type mbc3State struct {
+	RAMEnable bool
+	ROMBank   uint8
+	RAMBank   uint8
+	Latch     uint8
+}
+
+type mbc5State struct {
+	RAMEnable bool
+	ROMBank   uint16
+	RAMBank   uint8
+	Rumble    bool
+}
+
+// Write the cartridge state, including the memory bank controller, RAM and RTC.
+func (c *Cartridge) SaveState(w io.Writer) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	s := cartridgeState{
+		Type:           uint8(c.header.Type),
+		HeaderChecksum: c.header.HeaderChecksum,
+		GlobalChecksum: c.header.GlobalChecksum,
+		RAMSize:        uint32(len(c.ram)),
+	}
+	err := binary.Write(w, binary.LittleEndian, &s)
+	if err != nil {
+		return err
+	}
+
+	// Write the RAM.
+	_, err = w.Write(c.ram)
+	if err != nil {
+		return err
+	}
+
+	// Write the RTC.
+	if c.rtc != nil {
+		c.rtc.update()
+		rs := rtcState{
+			Registers: c.rtc.registers(),
+			Latched:   c.rtc.latched,
+			Last:      c.rtc.last.UnixNano(),
+		}
+		err = binary.Write(w, binary.LittleEndian, &rs)
+		if err != nil {
+			return err
+		}
+	}
+
+	return c.mbc.SaveState(w)
+}
+
+// Read the cartridge state. Fails if the state was saved from a different cartridge. If loading
+// fails, the cartridge is left unchanged.
+func (c *Cartridge) LoadState(r io.Reader) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	var s cartridgeState
+	err := binary.Read(r, binary.LittleEndian, &s)
+	if err != nil {
+		return err
+	}
+	if CartridgeType(s.Type) != c.header.Type || s.HeaderChecksum != c.header.HeaderChecksum ||
+		s.GlobalChecksum != c.header.GlobalChecksum || int(s.RAMSize) != len(c.ram) {
+		return fmt.Errorf("State is for a different cartridge")
+	}
+
+	// Read the RAM and RTC into temporary buffers, and only use them once the whole state is read.
+	ram := make([]uint8, len(c.ram))
+	_, err = io.ReadFull(r, ram)
+	if err != nil {
+		return err
+	}
+	var rs rtcState
+	if c.rtc != nil {
+		err = binary.Read(r, binary.LittleEndian, &rs)
+		if err != nil {
+			return err
+		}
+	}
+
+	// The memory bank controller is only changed if its state is read successfully.
+	err = c.mbc.LoadState(r)
+	if err != nil {
+		return err
+	}
+
+	copy(c.ram, ram)
+	c.dirty = true
+	if c.rtc != nil {
+		c.rtc.setRegisters(rs.Registers)
+		c.rtc.latched = rs.Latched
+		c.rtc.last = time.Unix(0, rs.Last)
+	}
+	return nil
+}
+
+func (m *romOnly) SaveState(w io.Writer) error {
+	return nil
+}
+
+func (m *romOnly) LoadState(r io.Reader) error {
+	return nil
+}
+
+func (m *mbc1) SaveState(w io.Writer) error {
+	return binary.Write(w, binary.LittleEndian, &mbc1State{m.ramEnable, m.bank1, m.bank2, m.mode})
+}
+
+func (m *mbc1) LoadState(r io.Reader) error {
+	var s mbc1State
+	err := binary.Read(r, binary.LittleEndian, &s)
+	if err != nil {
+		return err
+	}
+	m.ramEnable, m.bank1, m.bank2, m.mode = s.RAMEnable, s.Bank1, s.Bank2, s.Mode
+	return nil
+}
+
+func (m *mbc2) SaveState(w io.Writer) error {
+	return binary.Write(w, binary.LittleEndian, &mbc2State{m.ramEnable, m.romBank})
+}
+
+func (m *mbc2) LoadState(r io.Reader) error {
+	var s mbc2State
+	err := binary.Read(r, binary.LittleEndian, &s)
+	if err != nil {
+		return err
+	}
+	m.ramEnable, m.romBank = s.RAMEnable, s.ROMBank
+	return nil
+}
+
+func (m *mbc3) SaveState(w io.Writer) error {
+	return binary.Write(w, binary.LittleEndian, &mbc3State{m.ramEnable, m.romBank, m.ramBank, m.latch})
+}
+
+func (m *mbc3) LoadState(r io.Reader) error {
+	var s mbc3State
+	err := binary.Read(r, binary.LittleEndian, &s)
+	if err != nil {
+		return err
+	}
+	m.ramEnable, m.romBank, m.ramBank, m.latch = s.RAMEnable, s.ROMBank, s.RAMBank, s.Latch
+	return nil
+}
+
+func (m *mbc5) SaveState(w io.Writer) error {
+	return binary.Write(w, binary.LittleEndian, &mbc5State{m.ramEnable, m.romBank, m.ramBank, m.rumble})
+}
+
+func (m *mbc5) LoadState(r io.Reader) error {
+	var s mbc5State
+	err := binary.Read(r, binary.LittleEndian, &s)
+	if err != nil {
+		return err
+	}
+	m.ramEnable, m.romBank, m.ramBank = s.RAMEnable, s.ROMBank, s.RAMBank
+	m.setRumble(s.Rumble)
+	return nil
+}
@@ -0,0 +1,79 @@
+package cart
+
+import (
+	"bytes"
+	"testing"
+)
+
+// Make an MBC5 rumble cartridge with RAM enabled, recording rumble changes.
+func newRumbleCartridge(t *testing.T) (*Cartridge, *[]bool) {
+	c := newTestCartridge(t, TypeMBC5RumbleRAMBattery, 0x02)
+	var rumbles []bool
+	c.SetRumbleHandler(func(on bool) {
+		rumbles = append(rumbles, on)
+	})
+	c.WriteROM(MBC5RAMEnable, 0x0a)
+	return c, &rumbles
+}
+
+func TestStateRoundTrip(t *testing.T) {
+	c, _ := newRumbleCartridge(t)
+	c.WriteRAM(0x10, 0x42)
+	c.WriteROM(MBC5RAMBank, 0x08) // Rumble on.
+	var state bytes.Buffer
+	err := c.SaveState(&state)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, rumbles := newRumbleCartridge(t)
+	err = loaded.LoadState(&state)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v := loaded.ReadRAM(0x10); v != 0x42 {
+		t.Errorf("Wrong RAM after loading: %02x", v)
+	}
+	if len(*rumbles) != 1 || !(*rumbles)[0] {
+		t.Errorf("Wrong rumble changes after loading: %v", *rumbles)
+	}
+	if !loaded.dirty {
+		t.Error("RAM not marked dirty after loading")
+	}
+}
+
+func TestStateLoadFailure(t *testing.T) {
+	c, _ := newRumbleCartridge(t)
+	c.WriteRAM(0x10, 0x42)
+	c.WriteROM(MBC5RAMBank, 0x08)
+	var state bytes.Buffer
+	err := c.SaveState(&state)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Cut the memory bank controller state short. Nothing is changed by the failed load.
+	loaded, rumbles := newRumbleCartridge(t)
+	loaded.WriteRAM(0x10, 0x24)
+	loaded.dirty = false
+	data := state.Bytes()
+	err = loaded.LoadState(bytes.NewReader(data[:len(data)-1]))
+	if err == nil {
+		t.Fatal("Loaded a truncated state")
+	}
+	if v := loaded.ReadRAM(0x10); v != 0x24 {
+		t.Errorf("RAM changed by a failed load: %02x", v)
+	}
+	if loaded.dirty {
+		t.Error("RAM marked dirty by a failed load")
+	}
+	if len(*rumbles) != 0 {
+		t.Errorf("Rumble changed by a failed load: %v", *rumbles)
+	}
+
+	// States from other cartridges are rejected.
+	other := newTestCartridge(t, TypeMBC5RAMBattery, 0x02)
+	if err := other.LoadState(bytes.NewReader(data)); err == nil {
+		t.Error("Loaded a state from a different cartridge")
+	}
+}
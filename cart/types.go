@@ -0,0 +1,104 @@
+package cart
+
+// CartridgeType is the cartridge type byte in the header, which describes the memory bank
+// controller and any extra hardware on the cartridge.
+type CartridgeType uint8
+
+const (
+	TypeROM                  CartridgeType = 0x00
+	TypeMBC1                 CartridgeType = 0x01
+	TypeMBC1RAM              CartridgeType = 0x02
+	TypeMBC1RAMBattery       CartridgeType = 0x03
+	TypeMBC2                 CartridgeType = 0x05
+	TypeMBC2Battery          CartridgeType = 0x06
+	TypeROMRAM               CartridgeType = 0x08
+	TypeROMRAMBattery        CartridgeType = 0x09
+	TypeMMM01                CartridgeType = 0x0b
+	TypeMMM01RAM             CartridgeType = 0x0c
+	TypeMMM01RAMBattery      CartridgeType = 0x0d
+	TypeMBC3TimerBattery     CartridgeType = 0x0f
+	TypeMBC3TimerRAMBattery  CartridgeType = 0x10
+	TypeMBC3                 CartridgeType = 0x11
+	TypeMBC3RAM              CartridgeType = 0x12
+	TypeMBC3RAMBattery       CartridgeType = 0x13
+	TypeMBC5                 CartridgeType = 0x19
+	TypeMBC5RAM              CartridgeType = 0x1a
+	TypeMBC5RAMBattery       CartridgeType = 0x1b
+	TypeMBC5Rumble           CartridgeType = 0x1c
+	TypeMBC5RumbleRAM        CartridgeType = 0x1d
+	TypeMBC5RumbleRAMBattery CartridgeType = 0x1e
+	TypeMBC6                 CartridgeType = 0x20
+	TypeMBC7                 CartridgeType = 0x22
+	TypePocketCamera         CartridgeType = 0xfc
+	TypeTAMA5                CartridgeType = 0xfd
+	TypeHuC3                 CartridgeType = 0xfe
+	TypeHuC1RAMBattery       CartridgeType = 0xff
+)
+
+// Properties of a cartridge type.
+type cartridgeType struct {
+	name    string
+	ram     bool
+	battery bool
+	timer   bool
+	rumble  bool
+}
+
+var cartridgeTypes = map[CartridgeType]cartridgeType{
+	TypeROM:                  {"ROM", false, false, false, false},
+	TypeMBC1:                 {"MBC1", false, false, false, false},
+	TypeMBC1RAM:              {"MBC1+RAM", true, false, false, false},
+	TypeMBC1RAMBattery:       {"MBC1+RAM+BATTERY", true, true, false, false},
+	TypeMBC2:                 {"MBC2", false, false, false, false},
+	TypeMBC2Battery:          {"MBC2+BATTERY", false, true, false, false},
+	TypeROMRAM:               {"ROM+RAM", true, false, false, false},
+	TypeROMRAMBattery:        {"ROM+RAM+BATTERY", true, true, false, false},
+	TypeMMM01:                {"MMM01", false, false, false, false},
+	TypeMMM01RAM:             {"MMM01+RAM", true, false, false, false},
+	TypeMMM01RAMBattery:      {"MMM01+RAM+BATTERY", true, true, false, false},
+	TypeMBC3TimerBattery:     {"MBC3+TIMER+BATTERY", false, true, true, false},
+	TypeMBC3TimerRAMBattery:  {"MBC3+TIMER+RAM+BATTERY", true, true, true, false},
+	TypeMBC3:                 {"MBC3", false, false, false, false},
+	TypeMBC3RAM:              {"MBC3+RAM", true, false, false, false},
+	TypeMBC3RAMBattery:       {"MBC3+RAM+BATTERY", true, true, false, false},
+	TypeMBC5:                 {"MBC5", false, false, false, false},
+	TypeMBC5RAM:              {"MBC5+RAM", true, false, false, false},
+	TypeMBC5RAMBattery:       {"MBC5+RAM+BATTERY", true, true, false, false},
+	TypeMBC5Rumble:           {"MBC5+RUMBLE", false, false, false, true},
+	TypeMBC5RumbleRAM:        {"MBC5+RUMBLE+RAM", true, false, false, true},
+	TypeMBC5RumbleRAMBattery: {"MBC5+RUMBLE+RAM+BATTERY", true, true, false, true},
+	TypeMBC6:                 {"MBC6", true, true, false, false},
+	TypeMBC7:                 {"MBC7+SENSOR+RUMBLE+RAM+BATTERY", true, true, false, true},
+	TypePocketCamera:         {"POCKET CAMERA", true, true, false, false},
+	TypeTAMA5:                {"BANDAI TAMA5", true, true, true, false},
+	TypeHuC3:                 {"HuC3", true, true, true, false},
+	TypeHuC1RAMBattery:       {"HuC1+RAM+BATTERY", true, true, false, false},
+}
+
+// Get the name of the cartridge type.
+func (t CartridgeType) String() string {
+	if info, ok := cartridgeTypes[t]; ok {
+		return info.name
+	}
+	return "UNKNOWN"
+}
+
+// Get whether the cartridge has external RAM.
+func (t CartridgeType) HasRAM() bool {
+	return cartridgeTypes[t].ram
+}
+
+// Get whether the cartridge has a battery.
+func (t CartridgeType) HasBattery() bool {
+	return cartridgeTypes[t].battery
+}
+
+// Get whether the cartridge has a real-time clock.
+func (t CartridgeType) HasTimer() bool {
+	return cartridgeTypes[t].timer
+}
+
+// Get whether the cartridge has a rumble motor.
+func (t CartridgeType) HasRumble() bool {
+	return cartridgeTypes[t].rumble
+}
@@ -0,0 +1,66 @@
+package gb
+
+import (
+	"image/color"
+	"image/png"
+	"os"
+	"testing"
+
+	"github.com/ruiqimao/go-gb-emu/gb/ppu"
+)
+
+// Frames to run dmg-acid2 for. The test image is drawn within the first few frames and then stays
+// the same.
+const acid2Frames = 10
+
+// Run dmg-acid2 and compare the screen to the reference image. The reference image uses the grey
+// palette.
+func TestDMGAcid2(t *testing.T) {
+	gb := newTestROMGameBoy(t, "dmg-acid2.gb")
+	f, err := os.Open(testFilePath(t, "dmg-acid2.png"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	reference, err := png.Decode(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bounds := reference.Bounds()
+	if bounds.Dx() != ppu.FrameWidth || bounds.Dy() != ppu.FrameHeight {
+		t.Fatalf("Wrong reference image size: %v", bounds)
+	}
+
+	var frame Frame
+	for i := 0; i < acid2Frames; i++ {
+		next, err := gb.RunFrame()
+		if err == ErrNoFrame {
+			continue
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		frame = next
+	}
+	if frame == nil {
+		t.Fatal("No frame was finished")
+	}
+
+	img := frame.RGBA(PaletteGrey)
+	diff := 0
+	for y := 0; y < ppu.FrameHeight; y++ {
+		for x := 0; x < ppu.FrameWidth; x++ {
+			want := color.RGBAModel.Convert(reference.At(bounds.Min.X+x, bounds.Min.Y+y))
+			if img.RGBAAt(x, y) != want {
+				if diff == 0 {
+					t.Errorf("First difference at (%v, %v): got %v, expected %v", x, y,
+						img.RGBAAt(x, y), want)
+				}
+				diff++
+			}
+		}
+	}
+	if diff > 0 {
+		t.Errorf("%v pixels differ from the reference image", diff)
+	}
+}
@@ -0,0 +1,269 @@
+package apu
+
+import (
+	"math"
+)
+
+// Timing constants.
+const (
+	CPUClock = 4194304 // The APU is clocked at the CPU clock rate.
+)
+
+// Output constants.
+const (
+	DefaultSampleRate = 44100
+	SampleBufferSize  = 512 // Number of samples sent at a time.
+	SampleBuffers     = 16  // Number of buffers that can be queued before samples are dropped.
+)
+
+// A Sample is a single stereo output sample.
+type Sample struct {
+	L int16
+	R int16
+}
+
+// A Recorder receives every output sample, along with the output of each channel on its own.
+// Samples are passed to it synchronously as they are produced, so none are ever dropped.
+type Recorder interface {
+	Record(mix Sample, channels [4]Sample)
+}
+
+// Audio processing unit.
+// This implementation uses the Pan Docs and the Game Boy Sound Hardware page on the gbdev wiki as
+// references.
+type APU struct {
+	mmu MMU
+
+	// Channels.
+	ch1 *square
+	ch2 *square
+	ch3 *wave
+	ch4 *noise
+
+	// Control registers.
+	power bool
+	nr50  uint8
+	nr51  uint8
+
+	// Frame sequencer.
+	fsStep uint8
+	divBit bool
+
+	// Output sampling. Channel output is averaged over each output sample period.
+	sampleRate  int
+	sampleTimer int
+	sumN        int
+	out         accumulator
+	charge      float64
+	buffer      []Sample
+
+	// Recorder, and the output of each individual channel for it.
+	recorder   Recorder
+	channelOut [4]accumulator
+
+	// Output samples.
+	S chan []Sample
+}
+
+func NewAPU(sampleRate int) *APU {
+	a := &APU{
+		sampleRate: sampleRate,
+		buffer:     make([]Sample, 0, SampleBufferSize),
+		S:          make(chan []Sample, SampleBuffers),
+	}
+	a.ch1 = newSquare(true)
+	a.ch2 = newSquare(false)
+	a.ch3 = newWave()
+	a.ch4 = newNoise()
+
+	// The DAC capacitor keeps 0.999958 of its charge every clock.
+	a.charge = math.Pow(0.999958, float64(CPUClock)/float64(sampleRate))
+
+	return a
+}
+
+// Do an APU step. Consumes 1 clock.
+func (a *APU) Step() {
+	a.stepFrameSequencer()
+
+	if a.power {
+		a.ch1.Step()
+		a.ch2.Step()
+		a.ch3.Step()
+		a.ch4.Step()
+	}
+
+	a.mix()
+}
+
+// Clock the frame sequencer on the falling edge of bit 4 of DIV, which happens at 512Hz.
+// Step:   0   1   2   3   4   5   6   7
+// Length: x       x       x       x
+// Sweep:          x               x
+// Volume:                             x
+func (a *APU) stepFrameSequencer() {
+	divBit := false
+	if a.mmu != nil {
+		bit := uint(FrameSequencerDIVBit)
+		if a.mmu.DoubleSpeed() {
+			bit = FrameSequencerDIVBitDouble
+		}
+		divBit = a.mmu.DIV()&(0x1<<bit) != 0
+	}
+	falling := a.divBit && !divBit
+	a.divBit = divBit
+	if !falling || !a.power {
+		return
+	}
+
+	if a.fsStep%2 == 0 {
+		a.ch1.ClockLength()
+		a.ch2.ClockLength()
+		a.ch3.ClockLength()
+		a.ch4.ClockLength()
+	}
+	if a.fsStep == 2 || a.fsStep == 6 {
+		a.ch1.ClockSweep()
+	}
+	if a.fsStep == 7 {
+		a.ch1.ClockEnvelope()
+		a.ch2.ClockEnvelope()
+		a.ch4.ClockEnvelope()
+	}
+	a.fsStep = (a.fsStep + 1) % 8
+}
+
+// Get the analog output of each channel, in the range [-1, 1].
+func (a *APU) channelOutputs() [4]float64 {
+	return [4]float64{
+		dac(a.ch1.Output(), a.ch1.DACEnabled()),
+		dac(a.ch2.Output(), a.ch2.DACEnabled()),
+		dac(a.ch3.Output(), a.ch3.DACEnabled()),
+		dac(a.ch4.Output(), a.ch4.DACEnabled()),
+	}
+}
+
+// Mix the channels and accumulate the result into the current output sample.
+func (a *APU) mix() {
+	if a.power {
+		outputs := a.channelOutputs()
+		volL := float64((a.nr50>>4)&0x7+1) / 8
+		volR := float64(a.nr50&0x7+1) / 8
+		for i, out := range outputs {
+			// Pan the channel and scale it by the master volume.
+			var l, r float64
+			if a.nr51&(0x10<<i) != 0 {
+				l = out * volL
+			}
+			if a.nr51&(0x01<<i) != 0 {
+				r = out * volR
+			}
+
+			// Each side of the mix is the sum of up to 4 channels.
+			a.out.add(l/4, r/4)
+			if a.recorder != nil {
+				a.channelOut[i].add(l, r)
+			}
+		}
+	}
+	a.sumN++
+
+	// Check if it is time to output a sample.
+	a.sampleTimer += a.sampleRate
+	if a.sampleTimer < CPUClock {
+		return
+	}
+	a.sampleTimer -= CPUClock
+
+	mix := a.out.sample(a.sumN, a.charge)
+	if a.recorder != nil {
+		var channels [4]Sample
+		for i := range channels {
+			channels[i] = a.channelOut[i].sample(a.sumN, a.charge)
+		}
+		a.recorder.Record(mix, channels)
+	}
+	a.sumN = 0
+
+	a.pushSample(mix)
+}
+
+// Add a sample to the output buffer, and send the buffer if it is full.
+func (a *APU) pushSample(s Sample) {
+	a.buffer = append(a.buffer, s)
+	if len(a.buffer) < SampleBufferSize {
+		return
+	}
+
+	// Try to push the buffer. If the channel is full, drop the samples.
+	select {
+	case a.S <- a.buffer:
+	default:
+	}
+	a.buffer = make([]Sample, 0, SampleBufferSize)
+}
+
+// Get the sample rate.
+func (a *APU) SampleRate() int {
+	return a.sampleRate
+}
+
+// Attach a recorder, which is given every sample as it is produced. Pass nil to detach it.
+func (a *APU) AttachRecorder(recorder Recorder) {
+	a.recorder = recorder
+	a.channelOut = [4]accumulator{}
+}
+
+// Attach an MMU.
+func (a *APU) AttachMMU(mmu MMU) {
+	a.mmu = mmu
+}
+
+// Accumulates stereo output over a sample period, and removes the DC offset of the DACs with a
+// high-pass filter.
+type accumulator struct {
+	l    float64
+	r    float64
+	capL float64
+	capR float64
+}
+
+// Add to the accumulated output.
+func (acc *accumulator) add(l float64, r float64) {
+	acc.l += l
+	acc.r += r
+}
+
+// Clear the accumulated output.
+func (acc *accumulator) clear() {
+	acc.l, acc.r = 0, 0
+}
+
+// Average the accumulated output over n clocks and pass it through the high-pass filter. The
+// capacitor keeps the given fraction of its charge over the sample period.
+func (acc *accumulator) sample(n int, charge float64) Sample {
+	l := acc.l / float64(n)
+	r := acc.r / float64(n)
+	acc.clear()
+
+	outL := l - acc.capL
+	acc.capL = l - outL*charge
+	outR := r - acc.capR
+	acc.capR = r - outR*charge
+
+	return Sample{toInt16(outL), toInt16(outR)}
+}
+
+// Convert a channel's digital output to an analog value. Disabled DACs output 0.
+func dac(v uint8, enabled bool) float64 {
+	if !enabled {
+		return 0
+	}
+	return 1 - float64(v)/7.5
+}
+
+// Convert an analog value to a 16-bit sample.
+func toInt16(v float64) int16 {
+	v = math.Max(-1, math.Min(1, v))
+	return int16(v * math.MaxInt16)
+}
@@ -0,0 +1,94 @@
+package apu
+
+// Length counter. Disables its channel when it runs out, if enabled.
+type length struct {
+	max     uint16
+	counter uint16
+	enabled bool
+}
+
+// Load the length counter from the length register.
+func (l *length) load(v uint8) {
+	l.counter = l.max - uint16(v)
+}
+
+// Reload the length counter when the channel is triggered, if it has run out.
+func (l *length) trigger() {
+	if l.counter == 0 {
+		l.counter = l.max
+	}
+}
+
+// Clock the length counter. Returns whether the channel should be disabled.
+func (l *length) clock() bool {
+	if !l.enabled || l.counter == 0 {
+		return false
+	}
+	l.counter--
+	return l.counter == 0
+}
+
+// Volume envelope.
+type envelope struct {
+	// Register values.
+	initial uint8
+	add     bool
+	period  uint8
+
+	// Current state.
+	volume uint8
+	timer  uint8
+}
+
+// Get the envelope register.
+func (e *envelope) reg() uint8 {
+	v := e.initial<<4 | e.period
+	if e.add {
+		v |= 0x08
+	}
+	return v
+}
+
+// Set the envelope register.
+func (e *envelope) setReg(v uint8) {
+	e.initial = v >> 4
+	e.add = v&0x08 != 0
+	e.period = v & 0x07
+}
+
+// Get whether the DAC is enabled. The DAC is controlled by the upper 5 bits of the register.
+func (e *envelope) dacEnabled() bool {
+	return e.reg()&0xf8 != 0
+}
+
+// Restart the envelope when the channel is triggered.
+func (e *envelope) trigger() {
+	e.volume = e.initial
+	e.timer = periodOrEight(e.period)
+}
+
+// Clock the envelope. A period of 0 stops the envelope.
+func (e *envelope) clock() {
+	if e.period == 0 {
+		return
+	}
+	e.timer--
+	if e.timer > 0 {
+		return
+	}
+	e.timer = periodOrEight(e.period)
+
+	if e.add && e.volume < 0xf {
+		e.volume++
+	} else if !e.add && e.volume > 0x0 {
+		e.volume--
+	}
+}
+
+// Timers with a period of 0 are treated as having a period of 8.
+func periodOrEight(period uint8) uint8 {
+	if period == 0 {
+		return 8
+	}
+	return period
+}
@@ -0,0 +1,24 @@
+package apu
+
+// The frame sequencer is clocked by the falling edge of this bit of DIV. At double speed, DIV runs
+// twice as fast, so the next bit up is used.
+const (
+	FrameSequencerDIVBit       = 4
+	FrameSequencerDIVBitDouble = 5
+)
+
+// MMU interface.
+type MMU interface {
+	DIV() uint8
+	DoubleSpeed() bool
+}
+
+// Read from the wave RAM. The address is in the range [0x00, 0x10).
+func (a *APU) ReadWave(addr uint16) uint8 {
+	return a.ch3.ram[addr]
+}
+
+// Write to the wave RAM. The address is in the range [0x00, 0x10).
+func (a *APU) WriteWave(addr uint16, v uint8) {
+	a.ch3.ram[addr] = v
+}
@@ -0,0 +1,83 @@
+package apu
+
+// Noise channel divisors, selected by the lower 3 bits of NR43.
+var noiseDivisors = [8]uint16{8, 16, 32, 48, 64, 80, 96, 112}
+
+// Noise channel. Outputs the inverted low bit of a linear feedback shift register.
+type noise struct {
+	enabled bool
+
+	// Frequency timer.
+	shift   uint8
+	divisor uint8
+	timer   uint16
+
+	// Linear feedback shift register. In 7-bit mode, the feedback is also written to bit 6.
+	lfsr       uint16
+	shortWidth bool
+
+	length   length
+	envelope envelope
+}
+
+func newNoise() *noise {
+	return &noise{
+		length: length{max: 64},
+		lfsr:   0x7fff,
+	}
+}
+
+// Get the number of clocks between LFSR shifts.
+func (n *noise) period() uint16 {
+	return noiseDivisors[n.divisor] << n.shift
+}
+
+// Do a channel step. Consumes 1 clock.
+func (n *noise) Step() {
+	if n.timer > 0 {
+		n.timer--
+	}
+	if n.timer == 0 {
+		n.timer = n.period()
+
+		feedback := (n.lfsr ^ n.lfsr>>1) & 0x1
+		n.lfsr = n.lfsr>>1 | feedback<<14
+		if n.shortWidth {
+			n.lfsr = n.lfsr&^(0x1<<6) | feedback<<6
+		}
+	}
+}
+
+// Get the digital output of the channel, in the range [0x0, 0xf].
+func (n *noise) Output() uint8 {
+	if !n.enabled || n.lfsr&0x1 != 0 {
+		return 0
+	}
+	return n.envelope.volume
+}
+
+// Get whether the channel DAC is enabled.
+func (n *noise) DACEnabled() bool {
+	return n.envelope.dacEnabled()
+}
+
+// Trigger the channel.
+func (n *noise) trigger() {
+	n.enabled = n.DACEnabled()
+	n.timer = n.period()
+	n.lfsr = 0x7fff
+	n.length.trigger()
+	n.envelope.trigger()
+}
+
+// Clock the length counter.
+func (n *noise) ClockLength() {
+	if n.length.clock() {
+		n.enabled = false
+	}
+}
+
+// Clock the envelope.
+func (n *noise) ClockEnvelope() {
+	n.envelope.clock()
+}
@@ -0,0 +1,365 @@
+package apu
+
+import (
+	"github.com/ruiqimao/go-gb-emu/utils"
+)
+
+// NRx4 flags.
+const (
+	FlagTrigger      = 7
+	FlagLengthEnable = 6
+)
+
+// Other register flags.
+const (
+	FlagSweepNegate = 3 // NR10
+	FlagWaveDAC     = 7 // NR30
+	FlagShortWidth  = 3 // NR43
+	FlagAPUPower    = 7 // NR52
+)
+
+// Get the low byte of a frequency.
+func freqLow(freq uint16) uint8 {
+	return uint8(freq)
+}
+
+// Set the low byte of a frequency.
+func setFreqLow(freq uint16, v uint8) uint16 {
+	return freq&0x700 | uint16(v)
+}
+
+// Set the high bits of a frequency from an NRx4 register.
+func setFreqHigh(freq uint16, v uint8) uint16 {
+	return freq&0xff | uint16(v&0x7)<<8
+}
+
+// Get the NR10 register.
+func (a *APU) NR10() uint8 {
+	v := a.ch1.sweepPeriod<<4 | a.ch1.sweepShift
+	v = utils.SetBit(v, FlagSweepNegate, a.ch1.sweepNegate)
+	return v | 0x80
+}
+
+// Set the NR10 register.
+func (a *APU) SetNR10(v uint8) {
+	if !a.power {
+		return
+	}
+	a.ch1.sweepPeriod = (v >> 4) & 0x7
+	a.ch1.sweepNegate = utils.GetBit(v, FlagSweepNegate)
+	a.ch1.sweepShift = v & 0x7
+}
+
+// Get the NR11 register. The length is write-only.
+func (a *APU) NR11() uint8 {
+	return a.ch1.duty<<6 | 0x3f
+}
+
+// Set the NR11 register.
+func (a *APU) SetNR11(v uint8) {
+	if !a.power {
+		return
+	}
+	a.ch1.duty = v >> 6
+	a.ch1.length.load(v & 0x3f)
+}
+
+// Get the NR12 register.
+func (a *APU) NR12() uint8 {
+	return a.ch1.envelope.reg()
+}
+
+// Set the NR12 register.
+func (a *APU) SetNR12(v uint8) {
+	if !a.power {
+		return
+	}
+	a.ch1.envelope.setReg(v)
+	if !a.ch1.DACEnabled() {
+		a.ch1.enabled = false
+	}
+}
+
+// Get the NR13 register. This is write-only.
+func (a *APU) NR13() uint8 {
+	return 0xff
+}
+
+// Set the NR13 register.
+func (a *APU) SetNR13(v uint8) {
+	if !a.power {
+		return
+	}
+	a.ch1.freq = setFreqLow(a.ch1.freq, v)
+}
+
+// Get the NR14 register. Only the length enable flag is readable.
+func (a *APU) NR14() uint8 {
+	return utils.SetBit(0xbf, FlagLengthEnable, a.ch1.length.enabled)
+}
+
+// Set the NR14 register.
+func (a *APU) SetNR14(v uint8) {
+	if !a.power {
+		return
+	}
+	a.ch1.freq = setFreqHigh(a.ch1.freq, v)
+	a.ch1.length.enabled = utils.GetBit(v, FlagLengthEnable)
+	if utils.GetBit(v, FlagTrigger) {
+		a.ch1.trigger()
+	}
+}
+
+// Get the NR21 register. The length is write-only.
+func (a *APU) NR21() uint8 {
+	return a.ch2.duty<<6 | 0x3f
+}
+
+// Set the NR21 register.
+func (a *APU) SetNR21(v uint8) {
+	if !a.power {
+		return
+	}
+	a.ch2.duty = v >> 6
+	a.ch2.length.load(v & 0x3f)
+}
+
+// Get the NR22 register.
+func (a *APU) NR22() uint8 {
+	return a.ch2.envelope.reg()
+}
+
+// Set the NR22 register.
+func (a *APU) SetNR22(v uint8) {
+	if !a.power {
+		return
+	}
+	a.ch2.envelope.setReg(v)
+	if !a.ch2.DACEnabled() {
+		a.ch2.enabled = false
+	}
+}
+
+// Get the NR23 register. This is write-only.
+func (a *APU) NR23() uint8 {
+	return 0xff
+}
+
+// Set the NR23 register.
+func (a *APU) SetNR23(v uint8) {
+	if !a.power {
+		return
+	}
+	a.ch2.freq = setFreqLow(a.ch2.freq, v)
+}
+
+// Get the NR24 register. Only the length enable flag is readable.
+func (a *APU) NR24() uint8 {
+	return utils.SetBit(0xbf, FlagLengthEnable, a.ch2.length.enabled)
+}
+
+// Set the NR24 register.
+func (a *APU) SetNR24(v uint8) {
+	if !a.power {
+		return
+	}
+	a.ch2.freq = setFreqHigh(a.ch2.freq, v)
+	a.ch2.length.enabled = utils.GetBit(v, FlagLengthEnable)
+	if utils.GetBit(v, FlagTrigger) {
+		a.ch2.trigger()
+	}
+}
+
+// Get the NR30 register.
+func (a *APU) NR30() uint8 {
+	return utils.SetBit(0x7f, FlagWaveDAC, a.ch3.dacEnabled)
+}
+
+// Set the NR30 register.
+func (a *APU) SetNR30(v uint8) {
+	if !a.power {
+		return
+	}
+	a.ch3.dacEnabled = utils.GetBit(v, FlagWaveDAC)
+	if !a.ch3.dacEnabled {
+		a.ch3.enabled = false
+	}
+}
+
+// Get the NR31 register. This is write-only.
+func (a *APU) NR31() uint8 {
+	return 0xff
+}
+
+// Set the NR31 register.
+func (a *APU) SetNR31(v uint8) {
+	if !a.power {
+		return
+	}
+	a.ch3.length.load(v)
+}
+
+// Get the NR32 register.
+func (a *APU) NR32() uint8 {
+	return a.ch3.level<<5 | 0x9f
+}
+
+// Set the NR32 register.
+func (a *APU) SetNR32(v uint8) {
+	if !a.power {
+		return
+	}
+	a.ch3.level = (v >> 5) & 0x3
+}
+
+// Get the NR33 register. This is write-only.
+func (a *APU) NR33() uint8 {
+	return 0xff
+}
+
+// Set the NR33 register.
+func (a *APU) SetNR33(v uint8) {
+	if !a.power {
+		return
+	}
+	a.ch3.freq = setFreqLow(a.ch3.freq, v)
+}
+
+// Get the NR34 register. Only the length enable flag is readable.
+func (a *APU) NR34() uint8 {
+	return utils.SetBit(0xbf, FlagLengthEnable, a.ch3.length.enabled)
+}
+
+// Set the NR34 register.
+func (a *APU) SetNR34(v uint8) {
+	if !a.power {
+		return
+	}
+	a.ch3.freq = setFreqHigh(a.ch3.freq, v)
+	a.ch3.length.enabled = utils.GetBit(v, FlagLengthEnable)
+	if utils.GetBit(v, FlagTrigger) {
+		a.ch3.trigger()
+	}
+}
+
+// Get the NR41 register. This is write-only.
+func (a *APU) NR41() uint8 {
+	return 0xff
+}
+
+// Set the NR41 register.
+func (a *APU) SetNR41(v uint8) {
+	if !a.power {
+		return
+	}
+	a.ch4.length.load(v & 0x3f)
+}
+
+// Get the NR42 register.
+func (a *APU) NR42() uint8 {
+	return a.ch4.envelope.reg()
+}
+
+// Set the NR42 register.
+func (a *APU) SetNR42(v uint8) {
+	if !a.power {
+		return
+	}
+	a.ch4.envelope.setReg(v)
+	if !a.ch4.DACEnabled() {
+		a.ch4.enabled = false
+	}
+}
+
+// Get the NR43 register.
+func (a *APU) NR43() uint8 {
+	v := a.ch4.shift<<4 | a.ch4.divisor
+	return utils.SetBit(v, FlagShortWidth, a.ch4.shortWidth)
+}
+
+// Set the NR43 register.
+func (a *APU) SetNR43(v uint8) {
+	if !a.power {
+		return
+	}
+	a.ch4.shift = v >> 4
+	a.ch4.shortWidth = utils.GetBit(v, FlagShortWidth)
+	a.ch4.divisor = v & 0x7
+}
+
+// Get the NR44 register. Only the length enable flag is readable.
+func (a *APU) NR44() uint8 {
+	return utils.SetBit(0xbf, FlagLengthEnable, a.ch4.length.enabled)
+}
+
+// Set the NR44 register.
+func (a *APU) SetNR44(v uint8) {
+	if !a.power {
+		return
+	}
+	a.ch4.length.enabled = utils.GetBit(v, FlagLengthEnable)
+	if utils.GetBit(v, FlagTrigger) {
+		a.ch4.trigger()
+	}
+}
+
+// Get the NR50 register.
+func (a *APU) NR50() uint8 {
+	return a.nr50
+}
+
+// Set the NR50 register.
+func (a *APU) SetNR50(v uint8) {
+	if !a.power {
+		return
+	}
+	a.nr50 = v
+}
+
+// Get the NR51 register.
+func (a *APU) NR51() uint8 {
+	return a.nr51
+}
+
+// Set the NR51 register.
+func (a *APU) SetNR51(v uint8) {
+	if !a.power {
+		return
+	}
+	a.nr51 = v
+}
+
+// Get the NR52 register.
+func (a *APU) NR52() uint8 {
+	v := uint8(0x70)
+	v = utils.SetBit(v, FlagAPUPower, a.power)
+	v = utils.SetBit(v, 0, a.ch1.enabled)
+	v = utils.SetBit(v, 1, a.ch2.enabled)
+	v = utils.SetBit(v, 2, a.ch3.enabled)
+	v = utils.SetBit(v, 3, a.ch4.enabled)
+	return v
+}
+
+// Set the NR52 register. Only the power flag is writable.
+func (a *APU) SetNR52(v uint8) {
+	power := utils.GetBit(v, FlagAPUPower)
+	switch {
+
+	// Powering off clears all of the registers, but not the wave RAM.
+	case a.power && !power:
+		waveRAM := a.ch3.ram
+		a.ch1 = newSquare(true)
+		a.ch2 = newSquare(false)
+		a.ch3 = newWave()
+		a.ch3.ram = waveRAM
+		a.ch4 = newNoise()
+		a.nr50 = 0x00
+		a.nr51 = 0x00
+
+	// Powering on resets the frame sequencer.
+	case !a.power && power:
+		a.fsStep = 0
+
+	}
+	a.power = power
+}
@@ -0,0 +1,131 @@
+package apu
+
+// Duty cycle waveforms.
+var dutyTable = [4][8]uint8{
+	{0, 0, 0, 0, 0, 0, 0, 1}, // 12.5%
+	{1, 0, 0, 0, 0, 0, 0, 1}, // 25%
+	{1, 0, 0, 0, 0, 1, 1, 1}, // 50%
+	{0, 1, 1, 1, 1, 1, 1, 0}, // 75%
+}
+
+// Square wave channel. Channel 1 also has a frequency sweep.
+type square struct {
+	enabled bool
+
+	// Frequency timer.
+	freq  uint16
+	timer uint16
+
+	// Duty cycle.
+	duty    uint8
+	dutyPos uint8
+
+	length   length
+	envelope envelope
+
+	// Frequency sweep.
+	hasSweep     bool
+	sweepPeriod  uint8
+	sweepNegate  bool
+	sweepShift   uint8
+	sweepTimer   uint8
+	sweepEnabled bool
+	sweepShadow  uint16
+}
+
+func newSquare(hasSweep bool) *square {
+	return &square{
+		hasSweep: hasSweep,
+		length:   length{max: 64},
+	}
+}
+
+// Do a channel step. Consumes 1 clock.
+func (s *square) Step() {
+	if s.timer > 0 {
+		s.timer--
+	}
+	if s.timer == 0 {
+		s.timer = (2048 - s.freq) * 4
+		s.dutyPos = (s.dutyPos + 1) % 8
+	}
+}
+
+// Get the digital output of the channel, in the range [0x0, 0xf].
+func (s *square) Output() uint8 {
+	if !s.enabled {
+		return 0
+	}
+	return dutyTable[s.duty][s.dutyPos] * s.envelope.volume
+}
+
+// Get whether the channel DAC is enabled.
+func (s *square) DACEnabled() bool {
+	return s.envelope.dacEnabled()
+}
+
+// Trigger the channel.
+func (s *square) trigger() {
+	s.enabled = s.DACEnabled()
+	s.timer = (2048 - s.freq) * 4
+	s.length.trigger()
+	s.envelope.trigger()
+
+	if s.hasSweep {
+		s.sweepShadow = s.freq
+		s.sweepTimer = periodOrEight(s.sweepPeriod)
+		s.sweepEnabled = s.sweepPeriod != 0 || s.sweepShift != 0
+		if s.sweepShift != 0 {
+			s.sweepFrequency()
+		}
+	}
+}
+
+// Clock the length counter.
+func (s *square) ClockLength() {
+	if s.length.clock() {
+		s.enabled = false
+	}
+}
+
+// Clock the envelope.
+func (s *square) ClockEnvelope() {
+	s.envelope.clock()
+}
+
+// Clock the frequency sweep.
+func (s *square) ClockSweep() {
+	if !s.hasSweep {
+		return
+	}
+	s.sweepTimer--
+	if s.sweepTimer > 0 {
+		return
+	}
+	s.sweepTimer = periodOrEight(s.sweepPeriod)
+
+	if !s.sweepEnabled || s.sweepPeriod == 0 {
+		return
+	}
+	freq := s.sweepFrequency()
+	if freq <= 2047 && s.sweepShift != 0 {
+		s.sweepShadow = freq
+		s.freq = freq
+
+		// The new frequency is checked for overflow again, but not used.
+		s.sweepFrequency()
+	}
+}
+
+// Calculate the next sweep frequency. The channel is disabled if it overflows.
+func (s *square) sweepFrequency() uint16 {
+	delta := s.sweepShadow >> s.sweepShift
+	freq := s.sweepShadow + delta
+	if s.sweepNegate {
+		freq = s.sweepShadow - delta
+	}
+	if freq > 2047 {
+		s.enabled = false
+	}
+	return freq
+}
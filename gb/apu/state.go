@@ -0,0 +1,240 @@
+package apu
+
+import (
+	"encoding/binary"
+	"io"
+)
+
+// Serialized APU state. Host output state, such as partially accumulated samples, is not part of
+// the emulated machine and is not saved.
+type apuState struct {
+	Power  bool
+	NR50   uint8
+	NR51   uint8
+	FSStep uint8
+	DIVBit bool
+
+	Ch1 squareState
+	Ch2 squareState
+	Ch3 waveState
+	Ch4 noiseState
+}
+
+// Serialized length counter.
+type lengthState struct {
+	Counter uint16
+	Enabled bool
+}
+
+// Serialized envelope.
+type envelopeState struct {
+	Initial uint8
+	Add     bool
+	Period  uint8
+	Volume  uint8
+	Timer   uint8
+}
+
+// Serialized square channel.
+type squareState struct {
+	Enabled  bool
+	Freq     uint16
+	Timer    uint16
+	Duty     uint8
+	DutyPos  uint8
+	Length   lengthState
+	Envelope envelopeState
+
+	SweepPeriod  uint8
+	SweepNegate  bool
+	SweepShift   uint8
+	SweepTimer   uint8
+	SweepEnabled bool
+	SweepShadow  uint16
+}
+
+// Serialized wave channel.
+type waveState struct {
+	Enabled    bool
+	DACEnabled bool
+	Freq       uint16
+	Timer      uint16
+	Level      uint8
+	RAM        [0x10]uint8
+	Pos        uint8
+	Sample     uint8
+	Length     lengthState
+}
+
+// Serialized noise channel.
+type noiseState struct {
+	Enabled    bool
+	Shift      uint8
+	Divisor    uint8
+	Timer      uint16
+	LFSR       uint16
+	ShortWidth bool
+	Length     lengthState
+	Envelope   envelopeState
+}
+
+// Write the APU state.
+func (a *APU) SaveState(w io.Writer) error {
+	s := apuState{
+		Power:  a.power,
+		NR50:   a.nr50,
+		NR51:   a.nr51,
+		FSStep: a.fsStep,
+		DIVBit: a.divBit,
+
+		Ch1: a.ch1.state(),
+		Ch2: a.ch2.state(),
+		Ch3: a.ch3.state(),
+		Ch4: a.ch4.state(),
+	}
+	return binary.Write(w, binary.LittleEndian, &s)
+}
+
+// Read the APU state.
+func (a *APU) LoadState(r io.Reader) error {
+	var s apuState
+	err := binary.Read(r, binary.LittleEndian, &s)
+	if err != nil {
+		return err
+	}
+
+	a.power = s.Power
+	a.nr50 = s.NR50
+	a.nr51 = s.NR51
+	a.fsStep = s.FSStep
+	a.divBit = s.DIVBit
+
+	a.ch1.loadState(s.Ch1)
+	a.ch2.loadState(s.Ch2)
+	a.ch3.loadState(s.Ch3)
+	a.ch4.loadState(s.Ch4)
+
+	// Start accumulating a new output sample.
+	a.sumN = 0
+	a.out.clear()
+	for i := range a.channelOut {
+		a.channelOut[i].clear()
+	}
+	return nil
+}
+
+func (l *length) state() lengthState {
+	return lengthState{
+		Counter: l.counter,
+		Enabled: l.enabled,
+	}
+}
+
+func (l *length) loadState(s lengthState) {
+	l.counter = s.Counter
+	l.enabled = s.Enabled
+}
+
+func (e *envelope) state() envelopeState {
+	return envelopeState{
+		Initial: e.initial,
+		Add:     e.add,
+		Period:  e.period,
+		Volume:  e.volume,
+		Timer:   e.timer,
+	}
+}
+
+func (e *envelope) loadState(s envelopeState) {
+	e.initial = s.Initial
+	e.add = s.Add
+	e.period = s.Period
+	e.volume = s.Volume
+	e.timer = s.Timer
+}
+
+func (s *square) state() squareState {
+	return squareState{
+		Enabled:  s.enabled,
+		Freq:     s.freq,
+		Timer:    s.timer,
+		Duty:     s.duty,
+		DutyPos:  s.dutyPos,
+		Length:   s.length.state(),
+		Envelope: s.envelope.state(),
+
+		SweepPeriod:  s.sweepPeriod,
+		SweepNegate:  s.sweepNegate,
+		SweepShift:   s.sweepShift,
+		SweepTimer:   s.sweepTimer,
+		SweepEnabled: s.sweepEnabled,
+		SweepShadow:  s.sweepShadow,
+	}
+}
+
+func (s *square) loadState(st squareState) {
+	s.enabled = st.Enabled
+	s.freq = st.Freq
+	s.timer = st.Timer
+	s.duty = st.Duty & 0x3
+	s.dutyPos = st.DutyPos % 8
+	s.length.loadState(st.Length)
+	s.envelope.loadState(st.Envelope)
+
+	s.sweepPeriod = st.SweepPeriod
+	s.sweepNegate = st.SweepNegate
+	s.sweepShift = st.SweepShift
+	s.sweepTimer = st.SweepTimer
+	s.sweepEnabled = st.SweepEnabled
+	s.sweepShadow = st.SweepShadow
+}
+
+func (w *wave) state() waveState {
+	return waveState{
+		Enabled:    w.enabled,
+		DACEnabled: w.dacEnabled,
+		Freq:       w.freq,
+		Timer:      w.timer,
+		Level:      w.level,
+		RAM:        w.ram,
+		Pos:        w.pos,
+		Sample:     w.sample,
+		Length:     w.length.state(),
+	}
+}
+
+func (w *wave) loadState(s waveState) {
+	w.enabled = s.Enabled
+	w.dacEnabled = s.DACEnabled
+	w.freq = s.Freq
+	w.timer = s.Timer
+	w.level = s.Level & 0x3
+	w.ram = s.RAM
+	w.pos = s.Pos % 32
+	w.sample = s.Sample
+	w.length.loadState(s.Length)
+}
+
+func (n *noise) state() noiseState {
+	return noiseState{
+		Enabled:    n.enabled,
+		Shift:      n.shift,
+		Divisor:    n.divisor,
+		Timer:      n.timer,
+		LFSR:       n.lfsr,
+		ShortWidth: n.shortWidth,
+		Length:     n.length.state(),
+		Envelope:   n.envelope.state(),
+	}
+}
+
+func (n *noise) loadState(s noiseState) {
+	n.enabled = s.Enabled
+	n.shift = s.Shift
+	n.divisor = s.Divisor & 0x7
+	n.timer = s.Timer
+	n.lfsr = s.LFSR
+	n.shortWidth = s.ShortWidth
+	n.length.loadState(s.Length)
+	n.envelope.loadState(s.Envelope)
+}
@@ -0,0 +1,191 @@
+package apu
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// WAV format constants.
+const (
+	WAVHeaderSize = 44
+	WAVChannels   = 2
+	WAVBitDepth   = 16
+)
+
+// Writes stereo samples to a 16-bit PCM WAV stream. The sizes in the header are filled in when
+// the writer is closed.
+type WAVWriter struct {
+	ws  io.WriteSeeker
+	buf *bufio.Writer
+
+	// Number of samples written.
+	samples uint32
+}
+
+func NewWAVWriter(ws io.WriteSeeker, sampleRate int) (*WAVWriter, error) {
+	w := &WAVWriter{
+		ws:  ws,
+		buf: bufio.NewWriter(ws),
+	}
+
+	// Write the header. The sizes are written as 0 for now.
+	blockAlign := WAVChannels * WAVBitDepth / 8
+	header := []interface{}{
+		[4]uint8{'R', 'I', 'F', 'F'},
+		uint32(0),
+		[4]uint8{'W', 'A', 'V', 'E'},
+		[4]uint8{'f', 'm', 't', ' '},
+		uint32(16),                      // Format chunk size.
+		uint16(1),                       // PCM.
+		uint16(WAVChannels),             // Channels.
+		uint32(sampleRate),              // Sample rate.
+		uint32(sampleRate * blockAlign), // Byte rate.
+		uint16(blockAlign),              // Block align.
+		uint16(WAVBitDepth),             // Bits per sample.
+		[4]uint8{'d', 'a', 't', 'a'},
+		uint32(0),
+	}
+	for _, field := range header {
+		err := binary.Write(w.buf, binary.LittleEndian, field)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return w, nil
+}
+
+// Write a sample.
+func (w *WAVWriter) Write(s Sample) error {
+	err := binary.Write(w.buf, binary.LittleEndian, &s)
+	if err != nil {
+		return err
+	}
+	w.samples++
+	return nil
+}
+
+// Get the number of samples written.
+func (w *WAVWriter) Samples() uint32 {
+	return w.samples
+}
+
+// Flush the samples and fill in the header. This does not close the underlying stream.
+func (w *WAVWriter) Close() error {
+	err := w.buf.Flush()
+	if err != nil {
+		return err
+	}
+
+	dataSize := w.samples * WAVChannels * WAVBitDepth / 8
+	sizes := []struct {
+		offset int64
+		size   uint32
+	}{
+		{4, WAVHeaderSize - 8 + dataSize}, // RIFF chunk size.
+		{40, dataSize},                    // Data chunk size.
+	}
+	for _, s := range sizes {
+		_, err = w.ws.Seek(s.offset, io.SeekStart)
+		if err != nil {
+			return err
+		}
+		err = binary.Write(w.ws, binary.LittleEndian, s.size)
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err = w.ws.Seek(0, io.SeekEnd)
+	return err
+}
+
+// Records APU output to WAV files. The mix is written to the given path, and each channel can
+// optionally be written to its own file alongside it.
+type WAVRecorder struct {
+	files   []*os.File
+	writers []*WAVWriter
+
+	// First error encountered while recording. Recording stops once there is an error.
+	err error
+}
+
+// Create a WAV recorder. If perChannel is set, channel n is written to the path with "-chn"
+// added before the extension, such as "out-ch1.wav".
+func NewWAVRecorder(path string, sampleRate int, perChannel bool) (*WAVRecorder, error) {
+	paths := []string{path}
+	if perChannel {
+		for i := 1; i <= 4; i++ {
+			paths = append(paths, ChannelPath(path, i))
+		}
+	}
+
+	r := &WAVRecorder{}
+	for _, p := range paths {
+		f, err := os.Create(p)
+		if err != nil {
+			r.Close()
+			return nil, err
+		}
+		r.files = append(r.files, f)
+
+		w, err := NewWAVWriter(f, sampleRate)
+		if err != nil {
+			r.Close()
+			return nil, err
+		}
+		r.writers = append(r.writers, w)
+	}
+	return r, nil
+}
+
+// Get the path that a channel is recorded to, given the path of the mix. Channels are numbered
+// from 1.
+func ChannelPath(path string, channel int) string {
+	ext := filepath.Ext(path)
+	return fmt.Sprintf("%v-ch%v%v", strings.TrimSuffix(path, ext), channel, ext)
+}
+
+// Record a sample.
+func (r *WAVRecorder) Record(mix Sample, channels [4]Sample) {
+	if r.err != nil {
+		return
+	}
+	for i, w := range r.writers {
+		s := mix
+		if i > 0 {
+			s = channels[i-1]
+		}
+		err := w.Write(s)
+		if err != nil {
+			r.err = err
+			return
+		}
+	}
+}
+
+// Finish the WAV files and close them. Returns the first error encountered while recording, if
+// there was one.
+func (r *WAVRecorder) Close() error {
+	err := r.err
+	for i, f := range r.files {
+		if i < len(r.writers) {
+			werr := r.writers[i].Close()
+			if err == nil {
+				err = werr
+			}
+		}
+		ferr := f.Close()
+		if err == nil {
+			err = ferr
+		}
+	}
+	r.files = nil
+	r.writers = nil
+	return err
+}
@@ -0,0 +1,193 @@
+package apu
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// Parsed header of a 16-bit PCM WAV file.
+type wavHeader struct {
+	RIFF       [4]uint8
+	RIFFSize   uint32
+	WAVE       [4]uint8
+	Fmt        [4]uint8
+	FmtSize    uint32
+	Format     uint16
+	Channels   uint16
+	SampleRate uint32
+	ByteRate   uint32
+	BlockAlign uint16
+	BitDepth   uint16
+	Data       [4]uint8
+	DataSize   uint32
+}
+
+// Read a WAV file written by a WAVWriter, checking its header. Returns the samples.
+func readWAV(t *testing.T, path string, sampleRate int) []Sample {
+	t.Helper()
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(data) < WAVHeaderSize {
+		t.Fatalf("File too short: %v bytes", len(data))
+	}
+
+	var h wavHeader
+	err = binary.Read(bytes.NewReader(data), binary.LittleEndian, &h)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dataSize := uint32(len(data) - WAVHeaderSize)
+	expected := wavHeader{
+		RIFF:       [4]uint8{'R', 'I', 'F', 'F'},
+		RIFFSize:   WAVHeaderSize - 8 + dataSize,
+		WAVE:       [4]uint8{'W', 'A', 'V', 'E'},
+		Fmt:        [4]uint8{'f', 'm', 't', ' '},
+		FmtSize:    16,
+		Format:     1,
+		Channels:   2,
+		SampleRate: uint32(sampleRate),
+		ByteRate:   uint32(sampleRate * 4),
+		BlockAlign: 4,
+		BitDepth:   16,
+		Data:       [4]uint8{'d', 'a', 't', 'a'},
+		DataSize:   dataSize,
+	}
+	if h != expected {
+		t.Fatalf("Wrong header: %+v, expected %+v", h, expected)
+	}
+
+	// Samples are interleaved, left first.
+	samples := make([]Sample, dataSize/4)
+	for i := range samples {
+		samples[i].L = int16(binary.LittleEndian.Uint16(data[WAVHeaderSize+i*4:]))
+		samples[i].R = int16(binary.LittleEndian.Uint16(data[WAVHeaderSize+i*4+2:]))
+	}
+	return samples
+}
+
+// Check that samples were read back as written.
+func checkSamples(t *testing.T, path string, got []Sample, expected []Sample) {
+	t.Helper()
+	if len(got) != len(expected) {
+		t.Fatalf("%v: got %v samples, expected %v", path, len(got), len(expected))
+	}
+	for i := range got {
+		if got[i] != expected[i] {
+			t.Errorf("%v: sample %v is %v, expected %v", path, i, got[i], expected[i])
+		}
+	}
+}
+
+func TestWAVWriter(t *testing.T) {
+	dir, err := ioutil.TempDir("", "wav")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "out.wav")
+
+	samples := []Sample{{1, -1}, {0x1234, -0x1234}, {32767, -32768}, {0, 5}, {-7, 0}}
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w, err := NewWAVWriter(f, 44100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range samples {
+		err = w.Write(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if w.Samples() != uint32(len(samples)) {
+		t.Errorf("Wrote %v samples, expected %v", w.Samples(), len(samples))
+	}
+	err = w.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = f.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	checkSamples(t, path, readWAV(t, path, 44100), samples)
+}
+
+func TestWAVWriterEmpty(t *testing.T) {
+	dir, err := ioutil.TempDir("", "wav")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "out.wav")
+
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w, err := NewWAVWriter(f, 48000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = w.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	checkSamples(t, path, readWAV(t, path, 48000), nil)
+}
+
+func TestWAVRecorder(t *testing.T) {
+	dir, err := ioutil.TempDir("", "wav")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// The directory has a dot in it, so only the file name's extension is split off.
+	path := filepath.Join(dir, "rec.v2", "out.wav")
+	err = os.Mkdir(filepath.Dir(path), 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := NewWAVRecorder(path, 32768, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var expected [5][]Sample
+	for i := 0; i < 3; i++ {
+		mix := Sample{int16(i), int16(-i)}
+		var channels [4]Sample
+		for c := range channels {
+			channels[c] = Sample{int16(100*(c+1) + i), int16(-100*(c+1) - i)}
+			expected[c+1] = append(expected[c+1], channels[c])
+		}
+		expected[0] = append(expected[0], mix)
+		r.Record(mix, channels)
+	}
+	err = r.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	paths := []string{path}
+	for c := 1; c <= 4; c++ {
+		paths = append(paths, filepath.Join(dir, "rec.v2", fmt.Sprintf("out-ch%v.wav", c)))
+	}
+	for i, p := range paths {
+		checkSamples(t, p, readWAV(t, p, 32768), expected[i])
+	}
+}
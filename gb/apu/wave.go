@@ -0,0 +1,76 @@
+package apu
+
+// Wave channel output levels, as right shifts of the sample.
+var waveShifts = [4]uint8{4, 0, 1, 2}
+
+// Wave channel. Plays back 32 4-bit samples from the wave RAM.
+type wave struct {
+	enabled    bool
+	dacEnabled bool
+
+	// Frequency timer.
+	freq  uint16
+	timer uint16
+
+	// Output level.
+	level uint8
+
+	// Wave RAM and the current sample position.
+	ram    [0x10]uint8
+	pos    uint8
+	sample uint8
+
+	length length
+}
+
+func newWave() *wave {
+	return &wave{
+		length: length{max: 256},
+	}
+}
+
+// Do a channel step. Consumes 1 clock.
+func (w *wave) Step() {
+	if w.timer > 0 {
+		w.timer--
+	}
+	if w.timer == 0 {
+		w.timer = (2048 - w.freq) * 2
+		w.pos = (w.pos + 1) % 32
+
+		// Samples are played upper nibble first.
+		w.sample = w.ram[w.pos/2]
+		if w.pos%2 == 0 {
+			w.sample >>= 4
+		}
+		w.sample &= 0x0f
+	}
+}
+
+// Get the digital output of the channel, in the range [0x0, 0xf].
+func (w *wave) Output() uint8 {
+	if !w.enabled {
+		return 0
+	}
+	return w.sample >> waveShifts[w.level]
+}
+
+// Get whether the channel DAC is enabled.
+func (w *wave) DACEnabled() bool {
+	return w.dacEnabled
+}
+
+// Trigger the channel.
+func (w *wave) trigger() {
+	w.enabled = w.dacEnabled
+	w.timer = (2048 - w.freq) * 2
+	w.pos = 0
+	w.length.trigger()
+}
+
+// Clock the length counter.
+func (w *wave) ClockLength() {
+	if w.length.clock() {
+		w.enabled = false
+	}
+}
@@ -1,24 +1,33 @@
 package gb
 
 import (
+	"encoding/binary"
 	"fmt"
+	"io"
+)
+
+// Boot ROM sizes.
+const (
+	DMGBootROMSize = 0x100
+	CGBBootROMSize = 0x900
 )
 
 // Game Boy boot ROM.
 type BootROM struct {
-	rom     [0x100]uint8
+	rom     []uint8
 	enabled bool
 }
 
 func NewBootROM(rom []uint8) (*BootROM, error) {
-	if len(rom) != 0x100 {
+	if len(rom) != DMGBootROMSize && len(rom) != CGBBootROMSize {
 		return nil, fmt.Errorf("Improper Boot ROM size: %v", len(rom))
 	}
 
 	b := &BootROM{
+		rom:     make([]uint8, len(rom)),
 		enabled: true,
 	}
-	copy(b.rom[:], rom)
+	copy(b.rom, rom)
 	return b, nil
 }
 
@@ -27,6 +36,11 @@ func (b *BootROM) Read(addr uint16) uint8 {
 	return b.rom[addr]
 }
 
+// Get the size of the boot ROM.
+func (b *BootROM) Size() int {
+	return len(b.rom)
+}
+
 // Get the BOOT register.
 func (b *BootROM) BOOT() uint8 {
 	if b.enabled {
@@ -44,3 +58,13 @@ func (b *BootROM) SetBOOT(v uint8) {
 		b.enabled = true
 	}
 }
+
+// Write the boot ROM state.
+func (b *BootROM) SaveState(w io.Writer) error {
+	return binary.Write(w, binary.LittleEndian, b.enabled)
+}
+
+// Read the boot ROM state.
+func (b *BootROM) LoadState(r io.Reader) error {
+	return binary.Read(r, binary.LittleEndian, &b.enabled)
+}
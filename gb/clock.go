@@ -35,19 +35,26 @@ func (c *Clock) run() {
 		select {
 		case <-c.ticker.C:
 			c.mutex.Lock()
-			// Don't pipe signals if paused.
+			// Don't pipe signals if paused. The signal is dropped if the receiver is busy, so that
+			// the mutex is never held while blocking and the receiver can pause the clock itself.
 			if !c.paused {
-				c.C <- true
+				select {
+				case c.C <- true:
+				default:
+				}
 			}
 			c.mutex.Unlock()
 		}
 	}
 }
 
-func (c *Clock) Pause() {
+// Pause the clock. Returns whether it was running.
+func (c *Clock) Pause() bool {
 	c.mutex.Lock()
 	defer c.mutex.Unlock()
+	running := !c.paused
 	c.paused = true
+	return running
 }
 
 func (c *Clock) Resume() {
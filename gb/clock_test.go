@@ -0,0 +1,32 @@
+package gb
+
+import (
+	"testing"
+	"time"
+)
+
+func TestClockPause(t *testing.T) {
+	c := NewClock(1000)
+	c.Resume()
+	<-c.C
+
+	// Let ticks come in while nothing receives them, then pause. Run does this when the CPU faults.
+	time.Sleep(10 * time.Millisecond)
+	done := make(chan bool)
+	go func() {
+		c.Pause()
+		done <- true
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Pause blocked")
+	}
+
+	// No signals are sent once paused.
+	select {
+	case <-c.C:
+		t.Fatal("Signal sent while paused")
+	case <-time.After(20 * time.Millisecond):
+	}
+}
@@ -18,6 +18,14 @@ type CPU struct {
 	halt    bool
 	haltBug bool
 
+	// Whether the CPU has locked up from executing an illegal op code.
+	locked bool
+
+	// CGB speed switching.
+	cgb          bool
+	doubleSpeed  bool
+	prepareSpeed bool
+
 	// Interrupt flags.
 	ime bool
 	iE  uint8
@@ -68,6 +76,7 @@ func NewCPU() *CPU {
 
 		SetIME: c.setIME,
 		Halt:   c.triggerHalt,
+		Stop:   c.stop,
 
 		Nop: c.incrementMCycle,
 	}
@@ -82,11 +91,24 @@ func NewCPU() *CPU {
 func (c *CPU) Step() (int, error) {
 	c.clocks = 0
 
+	// A locked up CPU does nothing until it is reset.
+	if c.locked {
+		c.incrementMCycle()
+		return c.clocks, nil
+	}
+
+	// The CPU does nothing while a DMA transfer stalls it.
+	if c.mmu != nil && c.mmu.Stalled() {
+		c.incrementMCycle()
+		return c.clocks, nil
+	}
+
 	// Save the value of IME to use after the instruction has been executed.
 	ime := c.ime
 
 	// Execute an instruction.
 	if !c.halt {
+		pc := c.pc
 		op := uint16(c.popPC())
 		if op == 0xcb {
 			// CB prefixed operations are offset by 256 in the instruction set.
@@ -94,7 +116,9 @@ func (c *CPU) Step() (int, error) {
 		}
 		instruction := c.instructions[op]
 		if instruction == nil {
-			return c.clocks, fmt.Errorf("Invalid op code %02x", op)
+			// Illegal op codes lock up the CPU on real hardware.
+			c.locked = true
+			return c.clocks, &IllegalOpError{uint8(op), pc}
 		}
 		c.instructions[op](c.iio)
 	} else {
@@ -107,6 +131,21 @@ func (c *CPU) Step() (int, error) {
 	return c.clocks, nil
 }
 
+// An IllegalOpError is returned when the CPU executes one of the illegal op codes and locks up.
+type IllegalOpError struct {
+	Op uint8
+	PC uint16
+}
+
+func (e *IllegalOpError) Error() string {
+	return fmt.Sprintf("Illegal op code %02x at %04x", e.Op, e.PC)
+}
+
+// Get whether the CPU has locked up.
+func (c *CPU) Locked() bool {
+	return c.locked
+}
+
 // Attach an MMU.
 func (c *CPU) AttachMMU(mmu MMU) {
 	c.mmu = mmu
@@ -35,6 +35,7 @@ type InstructionIO struct {
 	// Interrupt access.
 	SetIME func(v bool)
 	Halt   func()
+	Stop   func()
 
 	// No-op used for cycle counting.
 	Nop func()
@@ -982,8 +983,9 @@ func opHALT() Instruction {
 // Generate a STOP instruction.
 func opSTOP() Instruction {
 	return func(io InstructionIO) {
-		// TODO: Properly implement.
-		io.Halt()
+		io.Stop()
+
+		// STOP is followed by an unused byte.
 		io.SetPC(io.PC() + 1)
 	}
 }
@@ -5,8 +5,7 @@ import (
 )
 
 const (
-	InterruptTimer  = 2
-	InterruptSerial = 3 // TODO: Move this out.
+	InterruptTimer = 2
 )
 
 // Handle interrupts.
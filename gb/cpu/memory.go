@@ -8,6 +8,7 @@ import (
 type MMU interface {
 	Read(uint16) uint8
 	Write(uint16, uint8)
+	Stalled() bool
 }
 
 // Read a byte from memory.
@@ -0,0 +1,58 @@
+package cpu
+
+import (
+	"github.com/ruiqimao/go-gb-emu/utils"
+)
+
+// KEY1 flags.
+const (
+	FlagCurrentSpeed = 7
+	FlagPrepareSpeed = 0
+)
+
+// Set whether the CPU is in CGB mode, which allows switching to double speed.
+func (c *CPU) SetCGB(cgb bool) {
+	c.cgb = cgb
+	if !cgb {
+		c.doubleSpeed = false
+		c.prepareSpeed = false
+	}
+}
+
+// Get whether the CPU is running at double speed. At double speed, each machine cycle takes 2
+// clocks instead of 4, and the timers run twice as fast.
+func (c *CPU) DoubleSpeed() bool {
+	return c.doubleSpeed
+}
+
+// Execute a STOP. In CGB mode, this switches speeds if a switch was prepared through KEY1.
+// Otherwise, the CPU halts.
+func (c *CPU) stop() {
+	if c.cgb && c.prepareSpeed {
+		c.doubleSpeed = !c.doubleSpeed
+		c.prepareSpeed = false
+
+		// The divider is reset by the switch.
+		c.ic = 0
+		return
+	}
+	c.triggerHalt()
+}
+
+// Get the KEY1 register.
+func (c *CPU) KEY1() uint8 {
+	if !c.cgb {
+		return 0xff
+	}
+	key1 := uint8(0x7e) // Unused bits always read 1.
+	key1 = utils.SetBit(key1, FlagCurrentSpeed, c.doubleSpeed)
+	key1 = utils.SetBit(key1, FlagPrepareSpeed, c.prepareSpeed)
+	return key1
+}
+
+// Set the KEY1 register. Only the prepare flag is writable.
+func (c *CPU) SetKEY1(v uint8) {
+	if c.cgb {
+		c.prepareSpeed = utils.GetBit(v, FlagPrepareSpeed)
+	}
+}
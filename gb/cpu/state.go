@@ -0,0 +1,82 @@
+package cpu
+
+import (
+	"encoding/binary"
+	"io"
+)
+
+// Serialized CPU state.
+type cpuState struct {
+	Rg      [0x8]uint8
+	SP      uint16
+	PC      uint16
+	Halt    bool
+	HaltBug bool
+	Locked  bool
+	IME     bool
+	IE      uint8
+	IF      uint8
+	IC      uint16
+	TIMA    uint8
+	TMA     uint8
+	TAC     uint8
+	OF      bool
+
+	CGB          bool
+	DoubleSpeed  bool
+	PrepareSpeed bool
+}
+
+// Write the CPU state.
+func (c *CPU) SaveState(w io.Writer) error {
+	s := cpuState{
+		Rg:      c.rg,
+		SP:      c.sp,
+		PC:      c.pc,
+		Halt:    c.halt,
+		HaltBug: c.haltBug,
+		Locked:  c.locked,
+		IME:     c.ime,
+		IE:      c.iE,
+		IF:      c.iF,
+		IC:      c.ic,
+		TIMA:    c.tima,
+		TMA:     c.tma,
+		TAC:     c.tac,
+		OF:      c.of,
+
+		CGB:          c.cgb,
+		DoubleSpeed:  c.doubleSpeed,
+		PrepareSpeed: c.prepareSpeed,
+	}
+	return binary.Write(w, binary.LittleEndian, &s)
+}
+
+// Read the CPU state.
+func (c *CPU) LoadState(r io.Reader) error {
+	var s cpuState
+	err := binary.Read(r, binary.LittleEndian, &s)
+	if err != nil {
+		return err
+	}
+
+	c.rg = s.Rg
+	c.sp = s.SP
+	c.pc = s.PC
+	c.halt = s.Halt
+	c.haltBug = s.HaltBug
+	c.locked = s.Locked
+	c.ime = s.IME
+	c.iE = s.IE
+	c.iF = s.IF
+	c.ic = s.IC
+	c.tima = s.TIMA
+	c.tma = s.TMA
+	c.tac = s.TAC
+	c.of = s.OF
+
+	c.cgb = s.CGB
+	c.doubleSpeed = s.DoubleSpeed
+	c.prepareSpeed = s.PrepareSpeed
+	return nil
+}
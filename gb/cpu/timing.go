@@ -6,7 +6,11 @@ import (
 
 // Increment by a machine cycle.
 func (c *CPU) incrementMCycle() {
-	c.clocks += 4
+	if c.doubleSpeed {
+		c.clocks += 2
+	} else {
+		c.clocks += 4
+	}
 	c.updateTimers()
 }
 
@@ -102,3 +106,8 @@ func (c *CPU) SetTAC(v uint8) {
 	// Only lower 3 bits are writable.
 	c.tac = v & 0x07
 }
+
+// Set the internal counter that DIV is taken from. Unlike writing DIV, this sets all 16 bits.
+func (c *CPU) SetCounter(v uint16) {
+	c.ic = v
+}
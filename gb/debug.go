@@ -4,25 +4,38 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/ruiqimao/go-gb-emu/gb/apu"
 	"github.com/ruiqimao/go-gb-emu/gb/cpu"
 	"github.com/ruiqimao/go-gb-emu/gb/joypad"
 	"github.com/ruiqimao/go-gb-emu/gb/mmu"
 	"github.com/ruiqimao/go-gb-emu/gb/ppu"
+	"github.com/ruiqimao/go-gb-emu/gb/serial"
 )
 
-// Run the Game Boy clock.
+// Run the Game Boy clock. Does nothing on a headless Game Boy.
 func (gb *GameBoy) Resume() {
-	gb.clk.Resume()
+	if gb.clk != nil {
+		gb.clk.Resume()
+	}
 }
 
-// Pause the Game Boy clock.
-func (gb *GameBoy) Pause() {
-	gb.clk.Pause()
+// Pause the Game Boy clock. Once this returns, the Game Boy loop is not running any clocks, so the
+// machine can be inspected or its state saved and loaded. Returns whether the clock was running.
+// Does nothing on a headless Game Boy.
+func (gb *GameBoy) Pause() bool {
+	if gb.clk == nil {
+		return false
+	}
+	reply := make(chan bool)
+	gb.pauses <- reply
+	return <-reply
 }
 
-// Step forward by one instruction. Returns how many cycles were taken.
-func (gb *GameBoy) Step() int {
-	return gb.RunClocks(1) + 1
+// Step forward by one instruction. Returns how many cycles were taken, and the fault if the
+// instruction caused one.
+func (gb *GameBoy) Step() (int, error) {
+	extra, err := gb.RunClocks(1)
+	return extra + 1, err
 }
 
 // Get a readable version of the current instruction.
@@ -65,6 +78,16 @@ func (gb *GameBoy) PPU() *ppu.PPU {
 	return gb.ppu
 }
 
+// Get the APU.
+func (gb *GameBoy) APU() *apu.APU {
+	return gb.apu
+}
+
+// Get the serial port.
+func (gb *GameBoy) Serial() *serial.Serial {
+	return gb.sio
+}
+
 // Get the joypad.
 func (gb *GameBoy) Joypad() *joypad.Joypad {
 	return gb.jp
@@ -75,7 +98,7 @@ func (gb *GameBoy) MMU() *mmu.MMU {
 	return gb.mmu
 }
 
-// Get the clock.
+// Get the clock. This is nil on a headless Game Boy.
 func (gb *GameBoy) Clock() *Clock {
 	return gb.clk
 }
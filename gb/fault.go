@@ -0,0 +1,39 @@
+package gb
+
+import (
+	"fmt"
+
+	"github.com/ruiqimao/go-gb-emu/gb/cpu"
+)
+
+// A Fault is reported when the emulated CPU does something that would hang real hardware, such as
+// executing an illegal op code. The Game Boy keeps running in the same state the hardware would
+// be in, so it can still be inspected.
+type Fault struct {
+	Op     uint8  // Op code that caused the fault.
+	PC     uint16 // Address of the op code.
+	Bank   int    // ROM bank mapped at the address.
+	Cycles uint64 // Number of clocks run before the fault.
+}
+
+func (f *Fault) Error() string {
+	return fmt.Sprintf("Illegal op code %02x at %02x:%04x after %d cycles", f.Op, f.Bank, f.PC, f.Cycles)
+}
+
+// Convert an error from the CPU into a Fault.
+func (gb *GameBoy) newFault(err error) error {
+	illegal, ok := err.(*cpu.IllegalOpError)
+	if !ok {
+		return err
+	}
+
+	f := &Fault{
+		Op:     illegal.Op,
+		PC:     illegal.PC,
+		Cycles: gb.cycles,
+	}
+	if gb.cart != nil && illegal.PC < 0x8000 {
+		f.Bank = gb.cart.ROMBank(illegal.PC)
+	}
+	return f
+}
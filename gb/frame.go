@@ -0,0 +1,103 @@
+package gb
+
+import (
+	"encoding/binary"
+	"fmt"
+	"image"
+	"image/color"
+	"strconv"
+	"strings"
+
+	"github.com/ruiqimao/go-gb-emu/gb/ppu"
+)
+
+// A Frame is a rendered frame. DMG frames hold a shade for each pixel, from 0 (lightest) to 3
+// (darkest). CGB frames hold a little endian 15-bit RGB color for each pixel.
+type Frame []uint8
+
+// A Palette maps the 4 DMG shades to colors, from lightest to darkest.
+type Palette [4]color.RGBA
+
+// Built-in palettes.
+var (
+	PaletteGrey   = NewPalette(0xffffff, 0xaaaaaa, 0x555555, 0x000000)
+	PaletteGreen  = NewPalette(0x9bbc0f, 0x8bac0f, 0x306230, 0x0f380f) // Original Game Boy.
+	PalettePocket = NewPalette(0xc4cfa1, 0x8b956d, 0x4d533c, 0x1f1f1f) // Game Boy Pocket.
+	PaletteLight  = NewPalette(0x00b581, 0x009a71, 0x00694a, 0x004f3b) // Game Boy Light backlight.
+)
+
+// Built-in palettes by name.
+var Palettes = map[string]Palette{
+	"grey":   PaletteGrey,
+	"green":  PaletteGreen,
+	"pocket": PalettePocket,
+	"light":  PaletteLight,
+}
+
+// Create a palette from 4 colors in 0xRRGGBB form, from lightest to darkest.
+func NewPalette(c0, c1, c2, c3 uint32) Palette {
+	var p Palette
+	for i, c := range []uint32{c0, c1, c2, c3} {
+		p[i] = color.RGBA{uint8(c >> 16), uint8(c >> 8), uint8(c), 0xff}
+	}
+	return p
+}
+
+// Parse a palette. This is either the name of a built-in palette, or 4 comma separated colors in
+// RRGGBB form, from lightest to darkest.
+func ParsePalette(s string) (Palette, error) {
+	if p, ok := Palettes[s]; ok {
+		return p, nil
+	}
+
+	fields := strings.Split(s, ",")
+	if len(fields) != 4 {
+		return Palette{}, fmt.Errorf("Unknown palette: %v", s)
+	}
+	var colors [4]uint32
+	for i, field := range fields {
+		field = strings.TrimPrefix(strings.TrimSpace(field), "#")
+		c, err := strconv.ParseUint(field, 16, 24)
+		if err != nil || len(field) != 6 {
+			return Palette{}, fmt.Errorf("Invalid palette color: %v", fields[i])
+		}
+		colors[i] = uint32(c)
+	}
+	return NewPalette(colors[0], colors[1], colors[2], colors[3]), nil
+}
+
+// Get whether the frame is a CGB frame.
+func (f Frame) CGB() bool {
+	return len(f) == ppu.FrameWidth*ppu.FrameHeight*ppu.RGB555Bytes
+}
+
+// Convert the frame to an RGBA image. DMG frames are colored with the palette, and CGB frames keep
+// their own colors. Frames of any other size, such as a nil frame, give a blank image in the
+// lightest color of the palette.
+func (f Frame) RGBA(palette Palette) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, ppu.FrameWidth, ppu.FrameHeight))
+	cgb := f.CGB()
+	dmg := len(f) == ppu.FrameWidth*ppu.FrameHeight*ppu.ShadeBytes
+	for i := 0; i < ppu.FrameWidth*ppu.FrameHeight; i++ {
+		c := palette[0]
+		if cgb {
+			c = rgb555(binary.LittleEndian.Uint16(f[i*ppu.RGB555Bytes:]))
+		} else if dmg {
+			c = palette[f[i]&0x3]
+		}
+		img.Pix[i*4+0] = c.R
+		img.Pix[i*4+1] = c.G
+		img.Pix[i*4+2] = c.B
+		img.Pix[i*4+3] = c.A
+	}
+	return img
+}
+
+// Convert a 15-bit RGB color to RGBA. Each 5 bit component is scaled up to 8 bits.
+func rgb555(v uint16) color.RGBA {
+	scale := func(c uint16) uint8 {
+		c &= 0x1f
+		return uint8(c<<3 | c>>2)
+	}
+	return color.RGBA{scale(v), scale(v >> 5), scale(v >> 10), 0xff}
+}
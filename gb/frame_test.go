@@ -0,0 +1,106 @@
+package gb
+
+import (
+	"image/color"
+	"testing"
+
+	"github.com/ruiqimao/go-gb-emu/gb/ppu"
+)
+
+func TestParsePalette(t *testing.T) {
+	for name, palette := range Palettes {
+		p, err := ParsePalette(name)
+		if err != nil || p != palette {
+			t.Errorf("Parse %v: got %v and %v", name, p, err)
+		}
+	}
+
+	// Colors are RRGGBB with an optional #, and spaces around them are ignored.
+	p, err := ParsePalette("#ffffff, 000000,#102030,AaBbCc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := NewPalette(0xffffff, 0x000000, 0x102030, 0xaabbcc)
+	if p != expected {
+		t.Errorf("Got %v, expected %v", p, expected)
+	}
+	if p[2] != (color.RGBA{0x10, 0x20, 0x30, 0xff}) {
+		t.Errorf("Wrong color: %v", p[2])
+	}
+
+	for _, s := range []string{
+		"",
+		"purple",
+		"ffffff,000000,102030",
+		"ffffff,000000,102030,aabbcc,ddeeff",
+		"ffffff,000000,102030,zzzzzz",
+		"ffffff,000000,102030,fff",
+		"ffffff,000000,102030,1aabbcc",
+		"ffffff,000000,102030,##aabbcc",
+		"ffffff,000000,102030,-abbcc",
+	} {
+		if _, err := ParsePalette(s); err == nil {
+			t.Errorf("Parsed %q", s)
+		}
+	}
+}
+
+func TestRGB555(t *testing.T) {
+	tests := []struct {
+		v uint16
+		c color.RGBA
+	}{
+		{0x0000, color.RGBA{0x00, 0x00, 0x00, 0xff}},
+		{0x7fff, color.RGBA{0xff, 0xff, 0xff, 0xff}},
+		{0x001f, color.RGBA{0xff, 0x00, 0x00, 0xff}},
+		{0x03e0, color.RGBA{0x00, 0xff, 0x00, 0xff}},
+		{0x7c00, color.RGBA{0x00, 0x00, 0xff, 0xff}},
+		{0x0001, color.RGBA{0x08, 0x00, 0x00, 0xff}},
+		{0x0010, color.RGBA{0x84, 0x00, 0x00, 0xff}},
+		{0x4210, color.RGBA{0x84, 0x84, 0x84, 0xff}},
+
+		// The unused top bit is ignored.
+		{0x8000, color.RGBA{0x00, 0x00, 0x00, 0xff}},
+	}
+	for _, test := range tests {
+		if c := rgb555(test.v); c != test.c {
+			t.Errorf("Convert %04x: got %v, expected %v", test.v, c, test.c)
+		}
+	}
+}
+
+func TestFrameRGBA(t *testing.T) {
+	// DMG frames are colored with the palette.
+	dmg := make(Frame, ppu.FrameWidth*ppu.FrameHeight*ppu.ShadeBytes)
+	dmg[1] = 3
+	img := dmg.RGBA(PaletteGreen)
+	if c := img.RGBAAt(0, 0); c != PaletteGreen[0] {
+		t.Errorf("Wrong DMG color: %v", c)
+	}
+	if c := img.RGBAAt(1, 0); c != PaletteGreen[3] {
+		t.Errorf("Wrong DMG color: %v", c)
+	}
+
+	// CGB frames keep their own colors.
+	cgb := make(Frame, ppu.FrameWidth*ppu.FrameHeight*ppu.RGB555Bytes)
+	cgb[2], cgb[3] = 0x1f, 0x7c
+	img = cgb.RGBA(PaletteGreen)
+	if c := img.RGBAAt(0, 0); c != (color.RGBA{0x00, 0x00, 0x00, 0xff}) {
+		t.Errorf("Wrong CGB color: %v", c)
+	}
+	if c := img.RGBAAt(1, 0); c != (color.RGBA{0xff, 0x00, 0xff, 0xff}) {
+		t.Errorf("Wrong CGB color: %v", c)
+	}
+
+	// Frames of other sizes give a blank image.
+	for _, f := range []Frame{nil, make(Frame, 10), append(dmg, 0)} {
+		img := f.RGBA(PaletteGreen)
+		for y := 0; y < ppu.FrameHeight; y++ {
+			for x := 0; x < ppu.FrameWidth; x++ {
+				if c := img.RGBAAt(x, y); c != PaletteGreen[0] {
+					t.Fatalf("Frame of %v bytes is not blank at (%v, %v): %v", len(f), x, y, c)
+				}
+			}
+		}
+	}
+}
@@ -1,13 +1,16 @@
 package gb
 
 import (
-	"log"
+	"errors"
+	"fmt"
 
 	"github.com/ruiqimao/go-gb-emu/cart"
+	"github.com/ruiqimao/go-gb-emu/gb/apu"
 	"github.com/ruiqimao/go-gb-emu/gb/cpu"
 	"github.com/ruiqimao/go-gb-emu/gb/joypad"
 	"github.com/ruiqimao/go-gb-emu/gb/mmu"
 	"github.com/ruiqimao/go-gb-emu/gb/ppu"
+	"github.com/ruiqimao/go-gb-emu/gb/serial"
 )
 
 const (
@@ -15,45 +18,120 @@ const (
 	CPUClock  = 4194304 // CPU clock is 4.194304MHz.
 )
 
+// ErrNoFrame is returned by RunFrame when no frame is finished within a frame's worth of clocks.
+// This happens when the LCD is turned on partway through, since the first frame after that takes
+// longer than a full frame to arrive.
+var ErrNoFrame = errors.New("No frame was finished")
+
 type GameBoy struct {
 	mmu  *mmu.MMU
 	cpu  *cpu.CPU
 	ppu  *ppu.PPU
+	apu  *apu.APU
 	jp   *joypad.Joypad
+	sio  *serial.Serial
+	boot *BootROM
 	cart *cart.Cartridge
 
 	clk *Clock
 
+	// Whether the Game Boy is driven synchronously instead of by the clock.
+	headless bool
+
+	// Host audio sample rate.
+	sampleRate int
+
+	// Active audio recording.
+	recorder  *apu.WAVRecorder
+	recorders chan apu.Recorder
+
+	// Link cable peers to attach.
+	peers chan serial.LinkPeer
+
+	// Requests to pause the clock. Each request is answered with whether the clock was running.
+	pauses chan chan bool
+
+	// Number of extra clocks consumed by the last call to RunCycles.
+	clockDebt int
+
+	// Total number of clocks run.
+	cycles uint64
+
 	// Input events.
 	events chan joypad.Input
 
 	// Latest rendered frame.
-	F chan []byte
+	F chan Frame
+
+	// Audio samples.
+	A chan []apu.Sample
+
+	// Faults encountered while running. The clock is paused whenever a fault is sent.
+	Faults chan error
+}
+
+// An Option configures a GameBoy when it is created.
+type Option func(*GameBoy)
+
+// Create a headless Game Boy. A headless Game Boy has no clock and starts no goroutines. Instead,
+// it is driven synchronously with RunFrame and RunCycles.
+func Headless() Option {
+	return func(gb *GameBoy) {
+		gb.headless = true
+	}
+}
+
+// Set the sample rate of the audio output, in Hz. The default is apu.DefaultSampleRate.
+func SampleRate(rate int) Option {
+	return func(gb *GameBoy) {
+		gb.sampleRate = rate
+	}
 }
 
-func NewGameBoy() (*GameBoy, error) {
+func NewGameBoy(options ...Option) (*GameBoy, error) {
 	gb := &GameBoy{
-		events: make(chan joypad.Input, 16), // Allow a buffer of input events.
-		F:      make(chan []uint8, 1),
+		sampleRate: apu.DefaultSampleRate,
+		events:     make(chan joypad.Input, 16), // Allow a buffer of input events.
+		recorders:  make(chan apu.Recorder),
+		peers:      make(chan serial.LinkPeer),
+		pauses:     make(chan chan bool),
+		F:          make(chan Frame, 1),
+		Faults:     make(chan error, 1),
+	}
+	for _, option := range options {
+		option(gb)
+	}
+	if gb.sampleRate <= 0 {
+		return nil, fmt.Errorf("Invalid sample rate: %v", gb.sampleRate)
 	}
 
 	// Create the components.
 	gb.mmu = mmu.NewMMU()
 	gb.cpu = cpu.NewCPU()
 	gb.ppu = ppu.NewPPU()
+	gb.apu = apu.NewAPU(gb.sampleRate)
 	gb.jp = joypad.NewJoypad()
-	gb.clk = NewClock(BaseClock)
+	gb.sio = serial.NewSerial()
+	gb.A = gb.apu.S
 
 	// Attach components together.
 	gb.cpu.AttachMMU(gb.mmu.CPUBus())
 	gb.ppu.AttachMMU(gb.mmu.PPUBus())
+	gb.apu.AttachMMU(gb.mmu.APUBus())
 	gb.jp.AttachMMU(gb.mmu.JoypadBus())
+	gb.sio.AttachMMU(gb.mmu.SerialBus())
 
 	gb.mmu.AttachCPU(gb.cpu)
 	gb.mmu.AttachPPU(gb.ppu)
+	gb.mmu.AttachAPU(gb.apu)
 	gb.mmu.AttachJoypad(gb.jp)
+	gb.mmu.AttachSerial(gb.sio)
 
-	go gb.Run()
+	// Headless Game Boys are run by the caller.
+	if !gb.headless {
+		gb.clk = NewClock(BaseClock)
+		go gb.Run()
+	}
 
 	return gb, nil
 }
@@ -67,11 +145,29 @@ func (gb *GameBoy) Run() {
 		select {
 
 		case <-gb.clk.C:
-			clockDebt = gb.RunClocks(CPUClock/BaseClock - clockDebt)
+			var err error
+			clockDebt, err = gb.RunClocks(CPUClock/BaseClock - clockDebt)
+			if err != nil {
+				// Stop so that the fault can be inspected.
+				gb.clk.Pause()
+				select {
+				case gb.Faults <- err:
+				default:
+				}
+			}
 
 		case event := <-gb.events:
 			gb.jp.Handle(event)
 
+		case recorder := <-gb.recorders:
+			gb.apu.AttachRecorder(recorder)
+
+		case peer := <-gb.peers:
+			gb.sio.AttachPeer(peer)
+
+		case reply := <-gb.pauses:
+			reply <- gb.clk.Pause()
+
 		case frame := <-gb.ppu.F:
 			select {
 			case gb.F <- frame:
@@ -83,23 +179,64 @@ func (gb *GameBoy) Run() {
 }
 
 // Run a number of clocks. Returns how many extra clocks above the given limit were taken.
-func (gb *GameBoy) RunClocks(limit int) int {
+// If the CPU faults, this stops early and returns the fault.
+func (gb *GameBoy) RunClocks(limit int) (int, error) {
 	for limit > 0 {
 
 		// Process an instruction.
 		clocks, err := gb.cpu.Step()
-		if err != nil {
-			log.Fatal(err)
-		}
 		limit -= clocks
 
 		// Catch the other components up to the CPU.
 		for i := 0; i < clocks; i++ {
 			gb.ppu.Step()
+			gb.apu.Step()
+			gb.sio.Step()
 			gb.mmu.Step()
 		}
+		gb.cycles += uint64(clocks)
+
+		if err != nil {
+			return -limit, gb.newFault(err)
+		}
 	}
-	return -limit
+	return -limit, nil
+}
+
+// Run until the next frame and return it. If the LCD is off, a blank frame is returned after a
+// frame's worth of clocks. If no frame is finished within a frame's worth of clocks, this returns
+// ErrNoFrame. If the CPU faults, this stops early and returns the fault.
+// This should only be used on a headless Game Boy.
+func (gb *GameBoy) RunFrame() (Frame, error) {
+	// Drop any frame that was finished before this call.
+	select {
+	case <-gb.ppu.F:
+	default:
+	}
+
+	for clocks := 0; clocks < ppu.FrameClocks; {
+		extra, err := gb.RunClocks(1)
+		clocks += extra + 1
+		if err != nil {
+			return nil, err
+		}
+
+		select {
+		case frame := <-gb.ppu.F:
+			return frame, nil
+		default:
+		}
+	}
+	return nil, ErrNoFrame
+}
+
+// Run a number of clocks. Instructions are not split, so any extra clocks taken are deducted from
+// the next call. If the CPU faults, this stops early and returns the fault.
+// This should only be used on a headless Game Boy.
+func (gb *GameBoy) RunCycles(n int) error {
+	var err error
+	gb.clockDebt, err = gb.RunClocks(n - gb.clockDebt)
+	return err
 }
 
 // Load the Boot ROM.
@@ -108,16 +245,92 @@ func (gb *GameBoy) LoadBootRom(rom []byte) error {
 	if err != nil {
 		return err
 	}
+	gb.boot = bootrom
 	gb.mmu.AttachBootROM(bootrom)
+	gb.updateCGB()
 	return nil
 }
 
 // Load a cartridge.
 func (gb *GameBoy) LoadCartridge(cartridge *cart.Cartridge) {
+	gb.cart = cartridge
 	gb.mmu.AttachCartridge(cartridge)
+	gb.updateCGB()
+}
+
+// Put the Game Boy in CGB mode if a CGB boot ROM is loaded and the cartridge supports the CGB. A
+// DMG boot ROM always runs cartridges in DMG mode, and SkipBoot picks the mode from its model.
+func (gb *GameBoy) updateCGB() {
+	cgb := gb.boot != nil && gb.boot.Size() == CGBBootROMSize
+	cgb = cgb && gb.cart != nil && gb.cart.Header().CGB()
+	gb.setCGB(cgb)
+}
+
+// Set whether the Game Boy is in CGB mode.
+func (gb *GameBoy) setCGB(cgb bool) {
+	gb.cpu.SetCGB(cgb)
+	gb.mmu.SetCGB(cgb)
+	gb.ppu.SetCGB(cgb)
+}
+
+// Get whether the Game Boy is in CGB mode.
+func (gb *GameBoy) CGB() bool {
+	return gb.mmu.CGB()
+}
+
+// Attach a recorder to the APU, or detach it with nil. Once this returns, the previous recorder
+// will not be given any more samples.
+func (gb *GameBoy) Record(recorder apu.Recorder) {
+	// Headless Game Boys have no loop, so attach the recorder immediately.
+	if gb.headless {
+		gb.apu.AttachRecorder(recorder)
+		return
+	}
+	gb.recorders <- recorder
+}
+
+// Start recording audio to a WAV file. If perChannel is set, each channel is also recorded to its
+// own file, named with apu.ChannelPath.
+func (gb *GameBoy) StartRecording(path string, perChannel bool) error {
+	if gb.recorder != nil {
+		return fmt.Errorf("Already recording")
+	}
+	recorder, err := apu.NewWAVRecorder(path, gb.sampleRate, perChannel)
+	if err != nil {
+		return err
+	}
+	gb.recorder = recorder
+	gb.Record(recorder)
+	return nil
+}
+
+// Stop recording audio and finish the WAV files. This does nothing if not recording.
+func (gb *GameBoy) StopRecording() error {
+	if gb.recorder == nil {
+		return nil
+	}
+	gb.Record(nil)
+	err := gb.recorder.Close()
+	gb.recorder = nil
+	return err
+}
+
+// Connect a link cable peer to the serial port, or disconnect it with nil.
+func (gb *GameBoy) AttachLinkPeer(peer serial.LinkPeer) {
+	// Headless Game Boys have no loop, so attach the peer immediately.
+	if gb.headless {
+		gb.sio.AttachPeer(peer)
+		return
+	}
+	gb.peers <- peer
 }
 
 // Register input.
 func (gb *GameBoy) Input(event joypad.Input) {
+	// Headless Game Boys have no loop to handle events, so handle them immediately.
+	if gb.headless {
+		gb.jp.Handle(event)
+		return
+	}
 	gb.events <- event
 }
@@ -0,0 +1,314 @@
+package gb
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/ruiqimao/go-gb-emu/cart"
+	"github.com/ruiqimao/go-gb-emu/gb/ppu"
+)
+
+// Longest instruction, in clocks.
+const maxInstructionClocks = 24
+
+// Make a 32 KiB ROM with code at the start. Without a boot ROM, execution starts at 0x0000.
+func testROM(code []uint8) []uint8 {
+	rom := make([]uint8, 0x8000)
+	copy(rom, code)
+	return rom
+}
+
+// Make a headless Game Boy running code.
+func newTestGameBoy(t *testing.T, code []uint8) *GameBoy {
+	gb, err := NewGameBoy(Headless())
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := cart.NewCartridge(testROM(code))
+	if err != nil {
+		t.Fatal(err)
+	}
+	gb.LoadCartridge(c)
+	return gb
+}
+
+// Turn on the LCD, then count up in A and write it to the background map forever.
+var countCode = []uint8{
+	0x3e, 0x91, // ld a, $91
+	0xe0, 0x40, // ldh ($40), a
+	0x3c,             // inc a
+	0xea, 0x00, 0x98, // ld ($9800), a
+	0x18, 0xfa, // jr -6
+}
+
+func TestRunFrame(t *testing.T) {
+	gb := newTestGameBoy(t, countCode)
+
+	// The first frame after the LCD is turned on is blank.
+	frame, err := gb.RunFrame()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(frame) != ppu.FrameWidth*ppu.FrameHeight*ppu.ShadeBytes {
+		t.Fatalf("Wrong frame size: %v", len(frame))
+	}
+	for _, shade := range frame {
+		if shade != ppu.ShadeOff {
+			t.Fatal("First frame is not blank")
+		}
+	}
+
+	// Later frames arrive a frame's worth of clocks apart. RunFrame returns after the instruction
+	// that finishes the frame, so the time between returns varies by up to an instruction.
+	for i := 0; i < 3; i++ {
+		start := gb.cycles
+		_, err := gb.RunFrame()
+		if err != nil {
+			t.Fatal(err)
+		}
+		diff := int(gb.cycles-start) - ppu.FrameClocks
+		if diff <= -maxInstructionClocks || diff >= maxInstructionClocks {
+			t.Errorf("Frame took %v clocks", ppu.FrameClocks+diff)
+		}
+	}
+}
+
+func TestRunFrameDeterministic(t *testing.T) {
+	a := newTestGameBoy(t, countCode)
+	b := newTestGameBoy(t, countCode)
+	for i := 0; i < 4; i++ {
+		frameA, errA := a.RunFrame()
+		frameB, errB := b.RunFrame()
+		if errA != nil || errB != nil {
+			t.Fatal(errA, errB)
+		}
+		if !bytes.Equal(frameA, frameB) || a.cycles != b.cycles {
+			t.Fatalf("Frame %v differs", i)
+		}
+	}
+}
+
+func TestRunFrameNoFrame(t *testing.T) {
+	// Wait about 11000 clocks, then turn on the LCD. The first frame then finishes later than a
+	// frame's worth of clocks after the start.
+	gb := newTestGameBoy(t, []uint8{
+		0x01, 0x90, 0x01, // ld bc, $0190
+		0x0b,       // dec bc
+		0x78,       // ld a, b
+		0xb1,       // or c
+		0x20, 0xfb, // jr nz, -5
+		0x3e, 0x91, // ld a, $91
+		0xe0, 0x40, // ldh ($40), a
+		0x18, 0xfe, // jr -2
+	})
+
+	frame, err := gb.RunFrame()
+	if err != ErrNoFrame || frame != nil {
+		t.Fatalf("Expected no frame, got %v bytes and %v", len(frame), err)
+	}
+	frame, err = gb.RunFrame()
+	if err != nil || len(frame) != ppu.FrameWidth*ppu.FrameHeight*ppu.ShadeBytes {
+		t.Fatalf("Expected a frame, got %v bytes and %v", len(frame), err)
+	}
+}
+
+func TestRunFrameLCDOff(t *testing.T) {
+	// Blank frames keep coming while the LCD is off.
+	gb := newTestGameBoy(t, []uint8{0x18, 0xfe}) // jr -2
+	for i := 0; i < 3; i++ {
+		frame, err := gb.RunFrame()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(frame) != ppu.FrameWidth*ppu.FrameHeight*ppu.ShadeBytes {
+			t.Fatalf("Wrong frame size: %v", len(frame))
+		}
+	}
+}
+
+func TestRunCycles(t *testing.T) {
+	gb := newTestGameBoy(t, countCode)
+
+	// Instructions are not split, so clocks taken past the limit are carried into the next call.
+	total := 0
+	for _, n := range []int{1, 5, 7, 100, 3, 4096, 1, 1, ppu.FrameClocks} {
+		err := gb.RunCycles(n)
+		if err != nil {
+			t.Fatal(err)
+		}
+		total += n
+		if gb.clockDebt < 0 || gb.clockDebt >= maxInstructionClocks {
+			t.Fatalf("Clock debt out of range: %v", gb.clockDebt)
+		}
+		if gb.cycles != uint64(total+gb.clockDebt) {
+			t.Fatalf("Ran %v clocks, expected %v plus a debt of %v", gb.cycles, total, gb.clockDebt)
+		}
+	}
+
+	// Running in small steps ends up at the same point as running all at once.
+	other := newTestGameBoy(t, countCode)
+	err := other.RunCycles(total)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if other.cycles != gb.cycles || other.cpu.PC() != gb.cpu.PC() {
+		t.Errorf("Stepping differs: %v clocks at %04x, expected %v clocks at %04x", other.cycles,
+			other.cpu.PC(), gb.cycles, gb.cpu.PC())
+	}
+}
+
+// Make a boot ROM of a size that sets up a DMG palette, turns on the LCD and jumps to the end of
+// the boot ROM to unmap it, like the real boot ROMs do.
+func testBootROM(size int) []uint8 {
+	boot := make([]uint8, size)
+	copy(boot, []uint8{
+		0x3e, 0xfc, // ld a, $fc
+		0xe0, 0x47, // ldh ($47), a
+		0x3e, 0x91, // ld a, $91
+		0xe0, 0x40, // ldh ($40), a
+		0x3e, 0x01, // ld a, $01
+		0xc3, 0xfe, 0x00, // jp $00fe
+	})
+	copy(boot[0xfe:], []uint8{
+		0xe0, 0x50, // ldh ($50), a
+	})
+	return boot
+}
+
+func TestBootROMModel(t *testing.T) {
+	tests := []struct {
+		bootSize int
+		cgbFlag  uint8
+		cgb      bool
+	}{
+		{DMGBootROMSize, 0x00, false},
+		{DMGBootROMSize, cart.CGBSupported, false},
+		{DMGBootROMSize, cart.CGBOnly, false},
+		{CGBBootROMSize, 0x00, false},
+		{CGBBootROMSize, cart.CGBSupported, true},
+		{CGBBootROMSize, cart.CGBOnly, true},
+	}
+	for _, test := range tests {
+		rom := testROM(nil)
+		copy(rom[cart.AddrHeader:], []uint8{0x18, 0xfe}) // jr -2
+		rom[cart.AddrCGBFlag] = test.cgbFlag
+		c, err := cart.NewCartridge(rom)
+		if err != nil {
+			t.Fatal(err)
+		}
+		gb, err := NewGameBoy(Headless())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// The boot ROM can be loaded before or after the cartridge.
+		gb.LoadCartridge(c)
+		err = gb.LoadBootRom(testBootROM(test.bootSize))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if gb.CGB() != test.cgb {
+			t.Errorf("Boot ROM of %v bytes with CGB flag %02x: got CGB %v", test.bootSize,
+				test.cgbFlag, gb.CGB())
+			continue
+		}
+		if test.cgb {
+			continue
+		}
+
+		// DMG frames are drawn with BGP, so the blank background is white.
+		var frame Frame
+		for i := 0; i < 2; i++ {
+			frame, err = gb.RunFrame()
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+		if frame.CGB() {
+			t.Errorf("Boot ROM of %v bytes with CGB flag %02x: got a CGB frame", test.bootSize,
+				test.cgbFlag)
+		}
+		if c := frame.RGBA(PaletteGrey).RGBAAt(0, 0); c != PaletteGrey[0] {
+			t.Errorf("Boot ROM of %v bytes with CGB flag %02x: got %v, expected white",
+				test.bootSize, test.cgbFlag, c)
+		}
+	}
+}
+
+func TestPause(t *testing.T) {
+	gb, err := NewGameBoy()
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := cart.NewCartridge(testROM(countCode))
+	if err != nil {
+		t.Fatal(err)
+	}
+	gb.LoadCartridge(c)
+
+	gb.Resume()
+	time.Sleep(50 * time.Millisecond)
+	if !gb.Pause() {
+		t.Error("Clock was not running")
+	}
+	if gb.Pause() {
+		t.Error("Clock was running after pausing")
+	}
+
+	// Nothing runs once paused, so the state stays the same.
+	cycles := gb.cycles
+	if cycles == 0 {
+		t.Fatal("Nothing ran before pausing")
+	}
+	var before, after bytes.Buffer
+	err = gb.SaveState(&before)
+	if err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(50 * time.Millisecond)
+	err = gb.SaveState(&after)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gb.cycles != cycles || !bytes.Equal(before.Bytes(), after.Bytes()) {
+		t.Error("Ran while paused")
+	}
+}
+
+func TestSkipBootPPU(t *testing.T) {
+	rom := testROM(nil)
+	copy(rom[cart.AddrHeader:], []uint8{0x18, 0xfe}) // jr -2
+	for i := cart.AddrLogo; i < cart.AddrTitle; i++ {
+		rom[i] = 0xff
+	}
+	c, err := cart.NewCartridge(rom)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gb, err := NewGameBoy(Headless())
+	if err != nil {
+		t.Fatal(err)
+	}
+	gb.LoadCartridge(c)
+	err = gb.SkipBoot(ModelDMG)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The LCD has been on since the boot ROM, so the first line starts with OAM search.
+	gb.ppu.Step()
+	if mode := ppu.Mode(gb.ppu.STAT() & 0x3); mode != ppu.ModeOAM {
+		t.Errorf("Wrong mode on the first line: %v", mode)
+	}
+
+	// The first frame shows the logo.
+	frame, err := gb.RunFrame()
+	if err != nil {
+		t.Fatal(err)
+	}
+	logo := frame.RGBA(PaletteGrey).RGBAAt(32, 64)
+	if logo != PaletteGrey[3] {
+		t.Errorf("Logo not shown in the first frame: got %v", logo)
+	}
+}
@@ -0,0 +1,34 @@
+package joypad
+
+import (
+	"encoding/binary"
+	"io"
+)
+
+// Serialized joypad state.
+type joypadState struct {
+	Input uint8
+	JOYP  uint8
+}
+
+// Write the joypad state.
+func (j *Joypad) SaveState(w io.Writer) error {
+	s := joypadState{
+		Input: j.input,
+		JOYP:  j.joyp,
+	}
+	return binary.Write(w, binary.LittleEndian, &s)
+}
+
+// Read the joypad state.
+func (j *Joypad) LoadState(r io.Reader) error {
+	var s joypadState
+	err := binary.Read(r, binary.LittleEndian, &s)
+	if err != nil {
+		return err
+	}
+
+	j.input = s.Input
+	j.joyp = s.JOYP
+	return nil
+}
@@ -78,8 +78,8 @@ const (
 	AddrNR51 = 0xff25 // Audio channel mapping.
 	AddrNR52 = 0xff26 // Audio channel control.
 	// Unmapped: FF27 - FF2F.
-	AddrWAVE = 0xff27 // Wave pattern.
-	// AddrWAVE: FF28 - FF3F.
+	AddrWAVE = 0xff30 // Wave pattern.
+	// AddrWAVE: FF31 - FF3F.
 	AddrLCDC = 0xff40 // LCD control.
 	AddrSTAT = 0xff41 // LCD status.
 	AddrSCY  = 0xff42 // Background vertical scroll.
@@ -92,9 +92,26 @@ const (
 	AddrOBP1 = 0xff49 // OBJ palette 1.
 	AddrWY   = 0xff4a // Window Y coordinate.
 	AddrWX   = 0xff4b // Window X coordinate.
-	// Unmapped: FF4C - FF4F.
-	AddrBOOT = 0xff50 // Boot ROM control.
-	// Unmapped: FF51 - FF7F.
+	// Unmapped: FF4C.
+	AddrKEY1 = 0xff4d // CGB speed switch.
+	// Unmapped: FF4E.
+	AddrVBK   = 0xff4f // CGB VRAM bank.
+	AddrBOOT  = 0xff50 // Boot ROM control.
+	AddrHDMA1 = 0xff51 // CGB HDMA source high.
+	AddrHDMA2 = 0xff52 // CGB HDMA source low.
+	AddrHDMA3 = 0xff53 // CGB HDMA destination high.
+	AddrHDMA4 = 0xff54 // CGB HDMA destination low.
+	AddrHDMA5 = 0xff55 // CGB HDMA length, mode and start.
+	AddrRP    = 0xff56 // CGB infrared port.
+	// Unmapped: FF57 - FF67.
+	AddrBCPS = 0xff68 // CGB background palette spec.
+	AddrBCPD = 0xff69 // CGB background palette data.
+	AddrOCPS = 0xff6a // CGB object palette spec.
+	AddrOCPD = 0xff6b // CGB object palette data.
+	AddrOPRI = 0xff6c // CGB object priority mode.
+	// Unmapped: FF6D - FF6F.
+	AddrSVBK = 0xff70 // CGB WRAM bank.
+	// Unmapped: FF71 - FF7F.
 	// High RAM: FF80 - FFFE.
 	AddrIE = 0xffff // Interrupts enabled.
 )
@@ -0,0 +1,180 @@
+package mmu
+
+// APU interface.
+type APU interface {
+	NR10() uint8
+	NR11() uint8
+	NR12() uint8
+	NR13() uint8
+	NR14() uint8
+	NR21() uint8
+	NR22() uint8
+	NR23() uint8
+	NR24() uint8
+	NR30() uint8
+	NR31() uint8
+	NR32() uint8
+	NR33() uint8
+	NR34() uint8
+	NR41() uint8
+	NR42() uint8
+	NR43() uint8
+	NR44() uint8
+	NR50() uint8
+	NR51() uint8
+	NR52() uint8
+
+	SetNR10(uint8)
+	SetNR11(uint8)
+	SetNR12(uint8)
+	SetNR13(uint8)
+	SetNR14(uint8)
+	SetNR21(uint8)
+	SetNR22(uint8)
+	SetNR23(uint8)
+	SetNR24(uint8)
+	SetNR30(uint8)
+	SetNR31(uint8)
+	SetNR32(uint8)
+	SetNR33(uint8)
+	SetNR34(uint8)
+	SetNR41(uint8)
+	SetNR42(uint8)
+	SetNR43(uint8)
+	SetNR44(uint8)
+	SetNR50(uint8)
+	SetNR51(uint8)
+	SetNR52(uint8)
+
+	ReadWave(uint16) uint8
+
+	WriteWave(uint16, uint8)
+}
+
+type APUBus struct {
+	mmu *MMU
+}
+
+// Get the DIV register, which clocks the frame sequencer.
+func (b *APUBus) DIV() uint8 {
+	if b.mmu.cpu == nil {
+		return 0x00
+	}
+	return b.mmu.cpu.DIV()
+}
+
+// Get whether the CPU is running at double speed, which makes DIV run twice as fast.
+func (b *APUBus) DoubleSpeed() bool {
+	if b.mmu.cpu == nil {
+		return false
+	}
+	return b.mmu.cpu.DoubleSpeed()
+}
+
+// Read from an APU register.
+func (m *MMU) readAPU(addr uint16) uint8 {
+	if addr >= AddrWAVE && addr < AddrLCDC {
+		return m.apu.ReadWave(addr - AddrWAVE)
+	}
+
+	switch addr {
+	case AddrNR10:
+		return m.apu.NR10()
+	case AddrNR11:
+		return m.apu.NR11()
+	case AddrNR12:
+		return m.apu.NR12()
+	case AddrNR13:
+		return m.apu.NR13()
+	case AddrNR14:
+		return m.apu.NR14()
+	case AddrNR21:
+		return m.apu.NR21()
+	case AddrNR22:
+		return m.apu.NR22()
+	case AddrNR23:
+		return m.apu.NR23()
+	case AddrNR24:
+		return m.apu.NR24()
+	case AddrNR30:
+		return m.apu.NR30()
+	case AddrNR31:
+		return m.apu.NR31()
+	case AddrNR32:
+		return m.apu.NR32()
+	case AddrNR33:
+		return m.apu.NR33()
+	case AddrNR34:
+		return m.apu.NR34()
+	case AddrNR41:
+		return m.apu.NR41()
+	case AddrNR42:
+		return m.apu.NR42()
+	case AddrNR43:
+		return m.apu.NR43()
+	case AddrNR44:
+		return m.apu.NR44()
+	case AddrNR50:
+		return m.apu.NR50()
+	case AddrNR51:
+		return m.apu.NR51()
+	case AddrNR52:
+		return m.apu.NR52()
+	}
+
+	// Unmapped registers read as all ones.
+	return 0xff
+}
+
+// Write to an APU register.
+func (m *MMU) writeAPU(addr uint16, v uint8) {
+	if addr >= AddrWAVE && addr < AddrLCDC {
+		m.apu.WriteWave(addr-AddrWAVE, v)
+		return
+	}
+
+	switch addr {
+	case AddrNR10:
+		m.apu.SetNR10(v)
+	case AddrNR11:
+		m.apu.SetNR11(v)
+	case AddrNR12:
+		m.apu.SetNR12(v)
+	case AddrNR13:
+		m.apu.SetNR13(v)
+	case AddrNR14:
+		m.apu.SetNR14(v)
+	case AddrNR21:
+		m.apu.SetNR21(v)
+	case AddrNR22:
+		m.apu.SetNR22(v)
+	case AddrNR23:
+		m.apu.SetNR23(v)
+	case AddrNR24:
+		m.apu.SetNR24(v)
+	case AddrNR30:
+		m.apu.SetNR30(v)
+	case AddrNR31:
+		m.apu.SetNR31(v)
+	case AddrNR32:
+		m.apu.SetNR32(v)
+	case AddrNR33:
+		m.apu.SetNR33(v)
+	case AddrNR34:
+		m.apu.SetNR34(v)
+	case AddrNR41:
+		m.apu.SetNR41(v)
+	case AddrNR42:
+		m.apu.SetNR42(v)
+	case AddrNR43:
+		m.apu.SetNR43(v)
+	case AddrNR44:
+		m.apu.SetNR44(v)
+	case AddrNR50:
+		m.apu.SetNR50(v)
+	case AddrNR51:
+		m.apu.SetNR51(v)
+	case AddrNR52:
+		m.apu.SetNR52(v)
+	}
+}
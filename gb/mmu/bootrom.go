@@ -7,4 +7,14 @@ type BootROM interface {
 	SetBOOT(uint8)
 
 	Read(uint16) uint8
+	Size() int
+}
+
+// Get whether the boot ROM is mapped at an address. The CGB boot ROM is larger, and leaves a gap
+// at 0100 - 01FF for the cartridge header.
+func (m *MMU) bootROMMapped(addr uint16) bool {
+	if m.bootrom == nil || m.bootrom.BOOT() != 0x0 {
+		return false
+	}
+	return addr < 0x0100 || (addr >= 0x0200 && int(addr) < m.bootrom.Size())
 }
@@ -1,9 +1,37 @@
 package mmu
 
+// Cartridge interface.
+// ROM addresses are in the range [0x0000, 0x8000), and RAM addresses are in the range
+// [0x0000, 0x2000). Writes to ROM are used to control the memory bank controller.
+// RAMEnabled() is whether the cartridge RAM is currently enabled. While it is disabled, the MMU
+// will not access the cartridge RAM at all: reads return open bus (0xFF) and writes are ignored.
 type Cartridge interface {
 	ReadROM(uint16) uint8
 	ReadRAM(uint16) uint8
 
 	WriteROM(uint16, uint8)
 	WriteRAM(uint16, uint8)
+
+	RAMEnabled() bool
+}
+
+// Value read from the bus when nothing drives it.
+const (
+	OpenBus = 0xff
+)
+
+// Read a byte from the cartridge RAM, honoring RAM enable.
+func (m *MMU) readCartRAM(addr uint16) uint8 {
+	if m.cartridge == nil || !m.cartridge.RAMEnabled() {
+		return OpenBus
+	}
+	return m.cartridge.ReadRAM(addr)
+}
+
+// Write a byte to the cartridge RAM, honoring RAM enable.
+func (m *MMU) writeCartRAM(addr uint16, v uint8) {
+	if m.cartridge == nil || !m.cartridge.RAMEnabled() {
+		return
+	}
+	m.cartridge.WriteRAM(addr, v)
 }
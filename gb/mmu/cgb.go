@@ -0,0 +1,73 @@
+package mmu
+
+// Work RAM constants.
+const (
+	WRAMBankSize = 0x1000
+	WRAMBanks    = 8
+)
+
+// Set whether the MMU is in CGB mode, which enables the WRAM banks and the CGB registers.
+func (m *MMU) SetCGB(cgb bool) {
+	m.cgb = cgb
+	m.svbk = 0x00
+	m.rp = 0x00
+	m.hdmaActive = false
+	m.hdmaClocks = 0
+}
+
+// Get whether the MMU is in CGB mode.
+func (m *MMU) CGB() bool {
+	return m.cgb
+}
+
+// Get the WRAM bank mapped at D000 - DFFF. Bank 0 cannot be selected, and maps bank 1 instead.
+func (m *MMU) wramBank() int {
+	bank := int(m.svbk & 0x7)
+	if bank == 0 {
+		bank = 1
+	}
+	return bank
+}
+
+// Get the offset into WRAM of an address in C000 - DFFF.
+func (m *MMU) wramOffset(addr uint16) int {
+	offset := int(addr - AddrWRAM0)
+	if offset < WRAMBankSize {
+		return offset
+	}
+	return m.wramBank()*WRAMBankSize + offset - WRAMBankSize
+}
+
+// Get the SVBK register.
+func (m *MMU) SVBK() uint8 {
+	if !m.cgb {
+		return 0xff
+	}
+	return m.svbk | 0xf8
+}
+
+// Set the SVBK register.
+func (m *MMU) SetSVBK(v uint8) {
+	if m.cgb {
+		m.svbk = v & 0x7
+	}
+}
+
+// Get the RP register. There is never any infrared light received, so the read bit is always 1.
+func (m *MMU) RP() uint8 {
+	if !m.cgb {
+		return 0xff
+	}
+	rp := m.rp&0xc1 | 0x3c
+	if m.rp&0xc0 == 0xc0 {
+		rp |= 0x02
+	}
+	return rp
+}
+
+// Set the RP register.
+func (m *MMU) SetRP(v uint8) {
+	if m.cgb {
+		m.rp = v & 0xc1
+	}
+}
@@ -8,6 +8,7 @@ type CPU interface {
 	TAC() uint8
 	IF() uint8
 	IE() uint8
+	KEY1() uint8
 
 	SetDIV(uint8)
 	SetTIMA(uint8)
@@ -15,7 +16,9 @@ type CPU interface {
 	SetTAC(uint8)
 	SetIF(uint8)
 	SetIE(uint8)
+	SetKEY1(uint8)
 
+	DoubleSpeed() bool
 	RequestInterrupt(int)
 }
 
@@ -53,6 +56,11 @@ func (b *CPUBus) Write(addr uint16, v uint8) {
 	b.mmu.write(addr, v)
 }
 
+// Get whether the CPU is stalled by a DMA transfer.
+func (b *CPUBus) Stalled() bool {
+	return b.mmu.hdmaStalled()
+}
+
 // Request an interrupt from the CPU.
 func (m *MMU) requestInterrupt(interrupt int) {
 	if m.cpu != nil {
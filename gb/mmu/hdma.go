@@ -0,0 +1,139 @@
+package mmu
+
+import (
+	"github.com/ruiqimao/go-gb-emu/utils"
+)
+
+// HDMA constants.
+const (
+	HDMABlockSize   = 0x10
+	HDMABlockClocks = 32 // Clocks to copy a block. This is the same at both speeds.
+	FlagHDMAHBlank  = 7  // HDMA5 bit that selects an HBlank transfer.
+)
+
+// Get the HDMA1 register. The HDMA source and destination registers are write-only.
+func (m *MMU) HDMA1() uint8 {
+	return 0xff
+}
+
+// Set the HDMA1 register, which holds the high byte of the source address.
+func (m *MMU) SetHDMA1(v uint8) {
+	if m.cgb {
+		m.hdmaSrc = uint16(v)<<8 | m.hdmaSrc&0x00f0
+	}
+}
+
+// Get the HDMA2 register.
+func (m *MMU) HDMA2() uint8 {
+	return 0xff
+}
+
+// Set the HDMA2 register, which holds the low byte of the source address. The lower 4 bits are
+// ignored.
+func (m *MMU) SetHDMA2(v uint8) {
+	if m.cgb {
+		m.hdmaSrc = m.hdmaSrc&0xff00 | uint16(v&0xf0)
+	}
+}
+
+// Get the HDMA3 register.
+func (m *MMU) HDMA3() uint8 {
+	return 0xff
+}
+
+// Set the HDMA3 register, which holds the high byte of the destination address in VRAM. The
+// upper 3 bits are ignored.
+func (m *MMU) SetHDMA3(v uint8) {
+	if m.cgb {
+		m.hdmaDst = uint16(v&0x1f)<<8 | m.hdmaDst&0x00f0
+	}
+}
+
+// Get the HDMA4 register.
+func (m *MMU) HDMA4() uint8 {
+	return 0xff
+}
+
+// Set the HDMA4 register, which holds the low byte of the destination address in VRAM. The lower
+// 4 bits are ignored.
+func (m *MMU) SetHDMA4(v uint8) {
+	if m.cgb {
+		m.hdmaDst = m.hdmaDst&0x1f00 | uint16(v&0xf0)
+	}
+}
+
+// Get the HDMA5 register. Bits 0-6 hold the number of blocks left minus 1, and bit 7 is clear
+// while an HBlank transfer is active. After a transfer finishes, this reads 0xff.
+func (m *MMU) HDMA5() uint8 {
+	if !m.cgb {
+		return 0xff
+	}
+	return utils.SetBit(m.hdmaLen, FlagHDMAHBlank, !m.hdmaActive)
+}
+
+// Set the HDMA5 register. This starts a transfer of (v&0x7f + 1) blocks. If bit 7 is clear, it is
+// a general purpose transfer that runs immediately and stalls the CPU until it is done. If bit 7 is
+// set, it is an HBlank transfer that copies one block at the start of each HBlank, or straight away
+// if the PPU is already in HBlank. Clearing bit 7
+// during an HBlank transfer cancels it instead.
+func (m *MMU) SetHDMA5(v uint8) {
+	if !m.cgb {
+		return
+	}
+
+	hblank := utils.GetBit(v, FlagHDMAHBlank)
+	if m.hdmaActive && m.hdmaHBlank && !hblank {
+		// Cancel the transfer. A block that is already being copied still finishes.
+		m.hdmaActive = false
+		return
+	}
+
+	m.hdmaLen = v & 0x7f
+	m.hdmaActive = true
+	m.hdmaHBlank = hblank
+	if !hblank {
+		m.hdmaClocks = HDMABlockClocks
+	} else if m.ppu != nil && m.ppu.InHBlank() {
+		// A transfer started during HBlank copies its first block in the current HBlank.
+		m.startHBlankDMA()
+	}
+}
+
+// Get whether the CPU is stalled by an HDMA transfer. The CPU is stalled while a block is being
+// copied.
+func (m *MMU) hdmaStalled() bool {
+	return m.hdmaClocks > 0
+}
+
+// Start copying a block for an HBlank transfer. Called by the PPU at the start of each HBlank.
+func (m *MMU) startHBlankDMA() {
+	if m.hdmaActive && m.hdmaHBlank && m.hdmaClocks == 0 {
+		m.hdmaClocks = HDMABlockClocks
+	}
+}
+
+// Do a step of HDMA. A byte is copied every 2 clocks.
+func (m *MMU) stepHDMA() {
+	m.hdmaClocks--
+	if m.hdmaClocks%2 == 0 {
+		m.write(AddrVRAM+m.hdmaDst, m.read(m.hdmaSrc))
+		m.hdmaSrc++
+		m.hdmaDst = (m.hdmaDst + 1) & 0x1fff
+	}
+
+	// Wait for the block to finish.
+	if m.hdmaClocks > 0 {
+		return
+	}
+
+	// Finish the transfer after the last block. The length wraps to 0x7f, so HDMA5 reads 0xff.
+	m.hdmaLen = (m.hdmaLen - 1) & 0x7f
+	if m.hdmaLen == 0x7f {
+		m.hdmaActive = false
+	}
+
+	// General purpose transfers move straight on to the next block.
+	if m.hdmaActive && !m.hdmaHBlank {
+		m.hdmaClocks = HDMABlockClocks
+	}
+}
@@ -0,0 +1,98 @@
+package mmu
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/ruiqimao/go-gb-emu/gb/ppu"
+)
+
+// Make a CGB MMU with 2 blocks of data in WRAM at C000.
+func newTestHDMA() (*MMU, *ppu.PPU, []uint8) {
+	m, _, p := newTestMMU()
+	m.SetCGB(true)
+	data := make([]uint8, HDMABlockSize*2)
+	for i := range data {
+		data[i] = uint8(i + 1)
+		m.Write(AddrWRAM0+uint16(i), data[i])
+	}
+	return m, p, data
+}
+
+// Start a transfer from C000 to 8000.
+func startHDMA(m *MMU, hdma5 uint8) {
+	m.Write(AddrHDMA1, 0xc0)
+	m.Write(AddrHDMA2, 0x00)
+	m.Write(AddrHDMA3, 0x80)
+	m.Write(AddrHDMA4, 0x00)
+	m.Write(AddrHDMA5, hdma5)
+}
+
+// Step the MMU until the transfer finishes.
+func finishHDMA(t *testing.T, m *MMU) {
+	t.Helper()
+	for i := 0; m.hdmaClocks > 0; i++ {
+		if i > HDMABlockClocks*0x80 {
+			t.Fatal("Transfer did not finish")
+		}
+		m.Step()
+	}
+}
+
+func TestHDMAStateMidBlock(t *testing.T) {
+	m, _, data := newTestHDMA()
+	startHDMA(m, 0x01)
+
+	// Save partway through the first block.
+	for i := 0; i < 10; i++ {
+		m.Step()
+	}
+	var state bytes.Buffer
+	err := m.SaveState(&state)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Load into a new MMU and finish the transfer. The VRAM is not part of the MMU state, so only
+	// the bytes copied after the save are checked.
+	loaded, _, p := newTestMMU()
+	err = loaded.LoadState(&state)
+	if err != nil {
+		t.Fatal(err)
+	}
+	finishHDMA(t, loaded)
+	copied := 5
+	if got := p.VRAM()[copied:len(data)]; !bytes.Equal(got, data[copied:]) {
+		t.Errorf("Wrong VRAM after loading: %v, expected %v", got, data[copied:])
+	}
+	if v := loaded.Read(AddrHDMA5); v != 0xff {
+		t.Errorf("Wrong HDMA5 after loading: %02x", v)
+	}
+}
+
+func TestHDMAStartInHBlank(t *testing.T) {
+	m, p, data := newTestHDMA()
+
+	// Run the PPU into the HBlank of a line. The PPU is not connected to the MMU, so the transfer
+	// is only started by the write to HDMA5.
+	m.Write(AddrLCDC, 0x91)
+	for i := 0; !p.InHBlank(); i++ {
+		if i > 456*2 {
+			t.Fatal("PPU did not reach HBlank")
+		}
+		p.Step()
+	}
+
+	startHDMA(m, 0x81)
+	finishHDMA(t, m)
+	if got := p.VRAM()[:HDMABlockSize]; !bytes.Equal(got, data[:HDMABlockSize]) {
+		t.Errorf("Wrong VRAM after the first block: %v", got)
+	}
+	second := p.VRAM()[HDMABlockSize : HDMABlockSize*2]
+	if !bytes.Equal(second, make([]uint8, HDMABlockSize)) {
+		t.Errorf("Second block copied without an HBlank: %v", second)
+	}
+	if v := m.Read(AddrHDMA5); v != 0x00 {
+		t.Errorf("Wrong HDMA5 after the first block: %02x", v)
+	}
+}
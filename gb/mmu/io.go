@@ -5,11 +5,14 @@ func (m *MMU) read(addr uint16) uint8 {
 	switch {
 
 	// Boot ROM.
-	case addr < 0x0100 && m.bootrom != nil && m.bootrom.BOOT() == 0x0:
+	case m.bootROMMapped(addr):
 		return m.bootrom.Read(addr)
 
 	// Cartridge ROM banks.
-	case addr >= AddrCartROM0 && addr < AddrVRAM && m.cartridge != nil:
+	case addr >= AddrCartROM0 && addr < AddrVRAM:
+		if m.cartridge == nil {
+			return OpenBus
+		}
 		return m.cartridge.ReadROM(addr)
 
 	// Video RAM.
@@ -18,11 +21,11 @@ func (m *MMU) read(addr uint16) uint8 {
 
 	// Cartridge RAM.
 	case addr >= AddrCartRAM && addr < AddrWRAM0:
-		return m.cartridge.ReadRAM(addr - AddrCartRAM)
+		return m.readCartRAM(addr - AddrCartRAM)
 
-	// Work RAM banks 0 and 1.
+	// Work RAM bank 0 and the switchable bank.
 	case addr >= AddrWRAM0 && addr < AddrEcho:
-		return m.wram[addr-AddrWRAM0]
+		return m.wram[m.wramOffset(addr)]
 
 	// Mirror of C000 - DDFF.
 	case addr >= AddrEcho && addr < AddrOAM:
@@ -66,12 +69,12 @@ func (m *MMU) write(addr uint16, v uint8) {
 		m.ppu.WriteVRAM(addr-AddrVRAM, v)
 
 	// Cartridge RAM.
-	case addr >= AddrCartRAM && addr < AddrWRAM0 && m.cartridge != nil:
-		m.cartridge.WriteROM(addr-AddrCartRAM, v)
+	case addr >= AddrCartRAM && addr < AddrWRAM0:
+		m.writeCartRAM(addr-AddrCartRAM, v)
 
-	// Work RAM banks 0 and 1.
+	// Work RAM bank 0 and the switchable bank.
 	case addr >= AddrWRAM0 && addr < AddrEcho:
-		m.wram[addr-AddrWRAM0] = v
+		m.wram[m.wramOffset(addr)] = v
 
 	// Mirror of C000 - DDFF.
 	case addr >= AddrEcho && addr < AddrOAM:
@@ -102,6 +105,15 @@ func (m *MMU) readIO(addr uint16) uint8 {
 		return m.joypad.JOYP()
 	}
 
+	if m.serial != nil {
+		switch addr {
+		case AddrSB:
+			return m.serial.SB()
+		case AddrSC:
+			return m.serial.SC()
+		}
+	}
+
 	if m.cpu != nil {
 		switch addr {
 		case AddrDIV:
@@ -114,6 +126,8 @@ func (m *MMU) readIO(addr uint16) uint8 {
 			return m.cpu.TAC()
 		case AddrIF:
 			return m.cpu.IF()
+		case AddrKEY1:
+			return m.cpu.KEY1()
 		}
 	}
 
@@ -143,13 +157,46 @@ func (m *MMU) readIO(addr uint16) uint8 {
 			return m.ppu.WY()
 		case AddrWX:
 			return m.ppu.WX()
+		case AddrVBK:
+			return m.ppu.VBK()
+		case AddrOPRI:
+			return m.ppu.OPRI()
+		case AddrBCPS:
+			return m.ppu.BCPS()
+		case AddrBCPD:
+			return m.ppu.BCPD()
+		case AddrOCPS:
+			return m.ppu.OCPS()
+		case AddrOCPD:
+			return m.ppu.OCPD()
 		}
 	}
 
+	if m.apu != nil && addr >= AddrNR10 && addr < AddrLCDC {
+		return m.readAPU(addr)
+	}
+
 	if m.bootrom != nil && addr == AddrBOOT {
 		return m.bootrom.BOOT()
 	}
 
+	switch addr {
+	case AddrHDMA1:
+		return m.HDMA1()
+	case AddrHDMA2:
+		return m.HDMA2()
+	case AddrHDMA3:
+		return m.HDMA3()
+	case AddrHDMA4:
+		return m.HDMA4()
+	case AddrHDMA5:
+		return m.HDMA5()
+	case AddrSVBK:
+		return m.SVBK()
+	case AddrRP:
+		return m.RP()
+	}
+
 	return 0x00
 }
 
@@ -159,6 +206,15 @@ func (m *MMU) writeIO(addr uint16, v uint8) {
 		m.joypad.SetJOYP(v)
 	}
 
+	if m.serial != nil {
+		switch addr {
+		case AddrSB:
+			m.serial.SetSB(v)
+		case AddrSC:
+			m.serial.SetSC(v)
+		}
+	}
+
 	if m.cpu != nil {
 		switch addr {
 		case AddrDIV:
@@ -171,6 +227,8 @@ func (m *MMU) writeIO(addr uint16, v uint8) {
 			m.cpu.SetTAC(v)
 		case AddrIF:
 			m.cpu.SetIF(v)
+		case AddrKEY1:
+			m.cpu.SetKEY1(v)
 		}
 	}
 
@@ -200,10 +258,43 @@ func (m *MMU) writeIO(addr uint16, v uint8) {
 			m.ppu.SetWY(v)
 		case AddrWX:
 			m.ppu.SetWX(v)
+		case AddrVBK:
+			m.ppu.SetVBK(v)
+		case AddrOPRI:
+			m.ppu.SetOPRI(v)
+		case AddrBCPS:
+			m.ppu.SetBCPS(v)
+		case AddrBCPD:
+			m.ppu.SetBCPD(v)
+		case AddrOCPS:
+			m.ppu.SetOCPS(v)
+		case AddrOCPD:
+			m.ppu.SetOCPD(v)
 		}
 	}
 
+	if m.apu != nil && addr >= AddrNR10 && addr < AddrLCDC {
+		m.writeAPU(addr, v)
+	}
+
 	if m.bootrom != nil && addr == AddrBOOT {
 		m.bootrom.SetBOOT(v)
 	}
+
+	switch addr {
+	case AddrHDMA1:
+		m.SetHDMA1(v)
+	case AddrHDMA2:
+		m.SetHDMA2(v)
+	case AddrHDMA3:
+		m.SetHDMA3(v)
+	case AddrHDMA4:
+		m.SetHDMA4(v)
+	case AddrHDMA5:
+		m.SetHDMA5(v)
+	case AddrSVBK:
+		m.SetSVBK(v)
+	case AddrRP:
+		m.SetRP(v)
+	}
 }
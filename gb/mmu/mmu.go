@@ -3,23 +3,40 @@ package mmu
 type MMU struct {
 	cpu    CPU
 	ppu    PPU
+	apu    APU
 	joypad Joypad
+	serial Serial
 
 	cpuBus    *CPUBus
 	ppuBus    *PPUBus
+	apuBus    *APUBus
 	joypadBus *JoypadBus
+	serialBus *SerialBus
 
 	bootrom BootROM
 
 	cartridge Cartridge
 
 	// RAM.
-	wram [0x2000]uint8
+	wram [WRAMBankSize * WRAMBanks]uint8
 	hram [0xff]uint8
 
+	// CGB mode and registers.
+	cgb  bool
+	svbk uint8
+	rp   uint8
+
 	// DMA.
 	dma       uint8
 	dmaClocks uint16
+
+	// CGB HDMA.
+	hdmaSrc    uint16
+	hdmaDst    uint16
+	hdmaLen    uint8
+	hdmaActive bool
+	hdmaHBlank bool
+	hdmaClocks uint8
 }
 
 func NewMMU() *MMU {
@@ -28,7 +45,9 @@ func NewMMU() *MMU {
 	// Create the buses.
 	m.cpuBus = &CPUBus{m}
 	m.ppuBus = &PPUBus{m}
+	m.apuBus = &APUBus{m}
 	m.joypadBus = &JoypadBus{m}
+	m.serialBus = &SerialBus{m}
 
 	return m
 }
@@ -39,6 +58,9 @@ func (m *MMU) Step() {
 		m.stepDMA()
 		m.dmaClocks--
 	}
+	if m.hdmaClocks > 0 {
+		m.stepHDMA()
+	}
 }
 
 // Attach a CPU.
@@ -51,11 +73,21 @@ func (m *MMU) AttachPPU(ppu PPU) {
 	m.ppu = ppu
 }
 
+// Attach an APU.
+func (m *MMU) AttachAPU(apu APU) {
+	m.apu = apu
+}
+
 // Attach a joypad.
 func (m *MMU) AttachJoypad(joypad Joypad) {
 	m.joypad = joypad
 }
 
+// Attach a serial port.
+func (m *MMU) AttachSerial(serial Serial) {
+	m.serial = serial
+}
+
 // Attach a boot ROM.
 func (m *MMU) AttachBootROM(bootrom BootROM) {
 	m.bootrom = bootrom
@@ -76,7 +108,17 @@ func (m *MMU) PPUBus() *PPUBus {
 	return m.ppuBus
 }
 
+// Get the APU bus.
+func (m *MMU) APUBus() *APUBus {
+	return m.apuBus
+}
+
 // Get the joypad bus.
 func (m *MMU) JoypadBus() *JoypadBus {
 	return m.joypadBus
 }
+
+// Get the serial bus.
+func (m *MMU) SerialBus() *SerialBus {
+	return m.serialBus
+}
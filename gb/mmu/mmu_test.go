@@ -0,0 +1,216 @@
+package mmu
+
+import (
+	"testing"
+
+	"github.com/ruiqimao/go-gb-emu/gb/cpu"
+	"github.com/ruiqimao/go-gb-emu/gb/ppu"
+)
+
+// A cartridge that records what the MMU does to it.
+type testCartridge struct {
+	rom        [0x8000]uint8
+	ram        [0x2000]uint8
+	ramEnabled bool
+
+	// Writes to ROM, for checking that banking writes go to the right place.
+	romWrites map[uint16]uint8
+}
+
+func newTestCartridge() *testCartridge {
+	c := &testCartridge{
+		romWrites: map[uint16]uint8{},
+	}
+	for i := range c.rom {
+		c.rom[i] = uint8(i) ^ uint8(i>>8)
+	}
+	return c
+}
+
+func (c *testCartridge) ReadROM(addr uint16) uint8 {
+	return c.rom[addr]
+}
+
+func (c *testCartridge) ReadRAM(addr uint16) uint8 {
+	return c.ram[addr]
+}
+
+func (c *testCartridge) WriteROM(addr uint16, v uint8) {
+	c.romWrites[addr] = v
+}
+
+func (c *testCartridge) WriteRAM(addr uint16, v uint8) {
+	c.ram[addr] = v
+}
+
+func (c *testCartridge) RAMEnabled() bool {
+	return c.ramEnabled
+}
+
+// Make an MMU with a CPU, PPU and cartridge attached.
+func newTestMMU() (*MMU, *testCartridge, *ppu.PPU) {
+	m := NewMMU()
+	c := newTestCartridge()
+	p := ppu.NewPPU()
+	m.AttachCPU(cpu.NewCPU())
+	m.AttachPPU(p)
+	m.AttachCartridge(c)
+	return m, c, p
+}
+
+// Check that a value written to an address reads back.
+func checkReadWrite(t *testing.T, m *MMU, addr uint16, v uint8) {
+	t.Helper()
+	m.Write(addr, v)
+	if got := m.Read(addr); got != v {
+		t.Errorf("Read %04x: got %02x, expected %02x", addr, got, v)
+	}
+}
+
+func TestNoCartridge(t *testing.T) {
+	m := NewMMU()
+	for _, addr := range []uint16{0x0000, 0x3fff, 0x4000, 0x7fff, 0xa000, 0xbfff} {
+		m.Write(addr, 0x12)
+		if v := m.Read(addr); v != OpenBus {
+			t.Errorf("Read %04x without a cartridge: %02x", addr, v)
+		}
+	}
+}
+
+func TestCartridgeROM(t *testing.T) {
+	m, c, _ := newTestMMU()
+
+	// ROM0 and ROMX are both read from the cartridge.
+	for _, addr := range []uint16{0x0000, 0x0150, 0x3fff, 0x4000, 0x5a5a, 0x7fff} {
+		if v := m.Read(addr); v != c.rom[addr] {
+			t.Errorf("Read %04x: got %02x, expected %02x", addr, v, c.rom[addr])
+		}
+	}
+
+	// Writes go to the memory bank controller and do not change the ROM.
+	for _, addr := range []uint16{0x0000, 0x2000, 0x4000, 0x7fff} {
+		old := c.rom[addr]
+		m.Write(addr, 0x5a)
+		if v, ok := c.romWrites[addr]; !ok || v != 0x5a {
+			t.Errorf("Write %04x not sent to the cartridge", addr)
+		}
+		if v := m.Read(addr); v != old {
+			t.Errorf("Write %04x changed ROM", addr)
+		}
+	}
+}
+
+func TestCartridgeRAM(t *testing.T) {
+	m, c, _ := newTestMMU()
+
+	// While RAM is disabled, reads are open bus and writes are dropped.
+	for _, addr := range []uint16{0xa000, 0xb123, 0xbfff} {
+		m.Write(addr, 0x34)
+		if v := c.ram[addr-AddrCartRAM]; v != 0x00 {
+			t.Errorf("Write %04x reached disabled RAM", addr)
+		}
+		if v := m.Read(addr); v != OpenBus {
+			t.Errorf("Read %04x from disabled RAM: %02x", addr, v)
+		}
+	}
+
+	// While RAM is enabled, reads and writes go to the cartridge RAM.
+	c.ramEnabled = true
+	for _, addr := range []uint16{0xa000, 0xb123, 0xbfff} {
+		checkReadWrite(t, m, addr, 0x34)
+		if v := c.ram[addr-AddrCartRAM]; v != 0x34 {
+			t.Errorf("Write %04x did not reach RAM", addr)
+		}
+	}
+
+	// RAM writes are never sent to the memory bank controller.
+	if len(c.romWrites) != 0 {
+		t.Errorf("RAM writes sent to ROM: %v", c.romWrites)
+	}
+}
+
+func TestVRAM(t *testing.T) {
+	m, _, p := newTestMMU()
+	for _, addr := range []uint16{0x8000, 0x8800, 0x9800, 0x9fff} {
+		checkReadWrite(t, m, addr, uint8(addr>>4))
+		if v := p.VRAM()[addr-AddrVRAM]; v != uint8(addr>>4) {
+			t.Errorf("Write %04x did not reach VRAM", addr)
+		}
+	}
+}
+
+func TestWRAM(t *testing.T) {
+	m, _, _ := newTestMMU()
+	for _, addr := range []uint16{0xc000, 0xcfff, 0xd000, 0xdfff} {
+		checkReadWrite(t, m, addr, uint8(addr>>8))
+	}
+}
+
+func TestEcho(t *testing.T) {
+	m, _, _ := newTestMMU()
+
+	// E000 - FDFF mirrors C000 - DDFF in both directions.
+	for _, addr := range []uint16{0xc000, 0xd000, 0xddff} {
+		m.Write(addr, 0x56)
+		if v := m.Read(addr + 0x2000); v != 0x56 {
+			t.Errorf("Read %04x: got %02x, expected mirror of %04x", addr+0x2000, v, addr)
+		}
+		m.Write(addr+0x2000, 0x65)
+		if v := m.Read(addr); v != 0x65 {
+			t.Errorf("Write %04x did not reach %04x", addr+0x2000, addr)
+		}
+	}
+}
+
+func TestOAM(t *testing.T) {
+	m, _, p := newTestMMU()
+	for _, addr := range []uint16{0xfe00, 0xfe50, 0xfe9f} {
+		checkReadWrite(t, m, addr, uint8(addr))
+		if v := p.OAM()[addr-AddrOAM]; v != uint8(addr) {
+			t.Errorf("Write %04x did not reach OAM", addr)
+		}
+	}
+}
+
+func TestUnusable(t *testing.T) {
+	m, _, _ := newTestMMU()
+	for _, addr := range []uint16{0xfea0, 0xfed0, 0xfeff} {
+		m.Write(addr, 0x78)
+		if v := m.Read(addr); v != 0x00 {
+			t.Errorf("Read %04x from unusable memory: %02x", addr, v)
+		}
+	}
+}
+
+func TestIO(t *testing.T) {
+	m, _, _ := newTestMMU()
+
+	// Registers owned by the CPU and the PPU.
+	checkReadWrite(t, m, AddrTMA, 0x9a)
+	checkReadWrite(t, m, AddrSCX, 0x9b)
+	checkReadWrite(t, m, AddrWX, 0x9c)
+
+	// Unused bits read back as 1.
+	m.Write(AddrIF, 0x01)
+	if v := m.Read(AddrIF); v != 0xe1 {
+		t.Errorf("Read IF: %02x", v)
+	}
+}
+
+func TestHRAM(t *testing.T) {
+	m, _, _ := newTestMMU()
+	for _, addr := range []uint16{0xff80, 0xffc0, 0xfffe} {
+		checkReadWrite(t, m, addr, uint8(addr))
+	}
+}
+
+func TestIE(t *testing.T) {
+	m, _, _ := newTestMMU()
+	checkReadWrite(t, m, AddrIE, 0x1f)
+
+	// IE is separate from the top of HRAM.
+	m.Write(0xfffe, 0x00)
+	if v := m.Read(AddrIE); v != 0x1f {
+		t.Errorf("Write fffe changed IE: %02x", v)
+	}
+}
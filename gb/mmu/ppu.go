@@ -13,6 +13,12 @@ type PPU interface {
 	OBP1() uint8
 	WY() uint8
 	WX() uint8
+	VBK() uint8
+	OPRI() uint8
+	BCPS() uint8
+	BCPD() uint8
+	OCPS() uint8
+	OCPD() uint8
 
 	SetLCDC(uint8)
 	SetSTAT(uint8)
@@ -25,6 +31,12 @@ type PPU interface {
 	SetOBP1(uint8)
 	SetWY(uint8)
 	SetWX(uint8)
+	SetVBK(uint8)
+	SetOPRI(uint8)
+	SetBCPS(uint8)
+	SetBCPD(uint8)
+	SetOCPS(uint8)
+	SetOCPD(uint8)
 
 	ReadVRAM(uint16) uint8
 	ReadOAM(uint16) uint8
@@ -34,6 +46,7 @@ type PPU interface {
 
 	VRAMAccessible() bool
 	OAMAccessible() bool
+	InHBlank() bool
 }
 
 type PPUBus struct {
@@ -43,3 +56,7 @@ type PPUBus struct {
 func (b *PPUBus) RequestInterrupt(interrupt int) {
 	b.mmu.requestInterrupt(interrupt)
 }
+
+func (b *PPUBus) HBlank() {
+	b.mmu.startHBlankDMA()
+}
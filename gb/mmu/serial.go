@@ -0,0 +1,18 @@
+package mmu
+
+// Serial port interface.
+type Serial interface {
+	SB() uint8
+	SC() uint8
+
+	SetSB(uint8)
+	SetSC(uint8)
+}
+
+type SerialBus struct {
+	mmu *MMU
+}
+
+func (b *SerialBus) RequestInterrupt(interrupt int) {
+	b.mmu.requestInterrupt(interrupt)
+}
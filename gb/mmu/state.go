@@ -0,0 +1,74 @@
+package mmu
+
+import (
+	"encoding/binary"
+	"io"
+)
+
+// Serialized MMU state. This only covers memory owned by the MMU; attached components serialize
+// themselves.
+type mmuState struct {
+	WRAM      [WRAMBankSize * WRAMBanks]uint8
+	HRAM      [0xff]uint8
+	DMA       uint8
+	DMAClocks uint16
+
+	CGB  bool
+	SVBK uint8
+	RP   uint8
+
+	HDMASrc    uint16
+	HDMADst    uint16
+	HDMALen    uint8
+	HDMAActive bool
+	HDMAHBlank bool
+	HDMAClocks uint8
+}
+
+// Write the MMU state.
+func (m *MMU) SaveState(w io.Writer) error {
+	s := mmuState{
+		WRAM:      m.wram,
+		HRAM:      m.hram,
+		DMA:       m.dma,
+		DMAClocks: m.dmaClocks,
+
+		CGB:  m.cgb,
+		SVBK: m.svbk,
+		RP:   m.rp,
+
+		HDMASrc:    m.hdmaSrc,
+		HDMADst:    m.hdmaDst,
+		HDMALen:    m.hdmaLen,
+		HDMAActive: m.hdmaActive,
+		HDMAHBlank: m.hdmaHBlank,
+		HDMAClocks: m.hdmaClocks,
+	}
+	return binary.Write(w, binary.LittleEndian, &s)
+}
+
+// Read the MMU state.
+func (m *MMU) LoadState(r io.Reader) error {
+	var s mmuState
+	err := binary.Read(r, binary.LittleEndian, &s)
+	if err != nil {
+		return err
+	}
+
+	m.wram = s.WRAM
+	m.hram = s.HRAM
+	m.dma = s.DMA
+	m.dmaClocks = s.DMAClocks
+
+	m.cgb = s.CGB
+	m.svbk = s.SVBK & 0x7
+	m.rp = s.RP
+
+	m.hdmaSrc = s.HDMASrc
+	m.hdmaDst = s.HDMADst & 0x1fff
+	m.hdmaLen = s.HDMALen & 0x7f
+	m.hdmaActive = s.HDMAActive
+	m.hdmaHBlank = s.HDMAHBlank
+	m.hdmaClocks = s.HDMAClocks
+	return nil
+}
@@ -0,0 +1,205 @@
+package gb
+
+import (
+	"fmt"
+
+	"github.com/ruiqimao/go-gb-emu/cart"
+	"github.com/ruiqimao/go-gb-emu/gb/cpu"
+	"github.com/ruiqimao/go-gb-emu/gb/mmu"
+)
+
+// Game Boy models. Each model's boot ROM leaves the Game Boy in a slightly different state.
+type Model int
+
+const (
+	ModelDMG0 Model = iota // Early original Game Boy.
+	ModelDMG               // Original Game Boy.
+	ModelMGB               // Game Boy Pocket.
+	ModelSGB               // Super Game Boy.
+	ModelCGB               // Game Boy Color.
+)
+
+// Get the name of the model.
+func (m Model) String() string {
+	switch m {
+	case ModelDMG0:
+		return "DMG0"
+	case ModelDMG:
+		return "DMG"
+	case ModelMGB:
+		return "MGB"
+	case ModelSGB:
+		return "SGB"
+	case ModelCGB:
+		return "CGB"
+	}
+	return fmt.Sprintf("Model(%d)", int(m))
+}
+
+// CPU state left by the boot ROM of a model.
+type postBootState struct {
+	af uint16
+	bc uint16
+	de uint16
+	hl uint16
+
+	// Internal timer counter. The upper bytes are documented, but the lower bytes are
+	// approximations.
+	counter uint16
+}
+
+var postBootStates = map[Model]postBootState{
+	ModelDMG0: {0x0100, 0xff13, 0x00c1, 0x8403, 0x1830},
+	ModelDMG:  {0x01b0, 0x0013, 0x00d8, 0x014d, 0xabcc},
+	ModelMGB:  {0xffb0, 0x0013, 0x00d8, 0x014d, 0xabcc},
+	ModelSGB:  {0x0100, 0x0014, 0x0000, 0xc060, 0xd85c},
+	ModelCGB:  {0x1180, 0x0000, 0xff56, 0x000d, 0x1ea0},
+}
+
+// IO registers left by the boot ROM, in the order they are written. The APU is powered on first so
+// that the other sound registers can be written, and only channel 1 is left playing.
+var postBootIO = []struct {
+	addr uint16
+	v    uint8
+}{
+	{mmu.AddrNR52, 0xf1},
+	{mmu.AddrNR10, 0x80},
+	{mmu.AddrNR11, 0xbf},
+	{mmu.AddrNR12, 0xf3},
+	{mmu.AddrNR13, 0xff},
+	{mmu.AddrNR14, 0xbf},
+	{mmu.AddrNR21, 0x3f},
+	{mmu.AddrNR22, 0x00},
+	{mmu.AddrNR23, 0xff},
+	{mmu.AddrNR24, 0xbf},
+	{mmu.AddrNR30, 0x7f},
+	{mmu.AddrNR31, 0xff},
+	{mmu.AddrNR32, 0x9f},
+	{mmu.AddrNR33, 0xff},
+	{mmu.AddrNR34, 0xbf},
+	{mmu.AddrNR41, 0xff},
+	{mmu.AddrNR42, 0x00},
+	{mmu.AddrNR43, 0x00},
+	{mmu.AddrNR44, 0xbf},
+	{mmu.AddrNR50, 0x77},
+	{mmu.AddrNR51, 0xf3},
+	{mmu.AddrJOYP, 0xcf},
+	{mmu.AddrTAC, 0xf8},
+	{mmu.AddrIF, 0xe1},
+	{mmu.AddrLCDC, 0x91},
+	{mmu.AddrSTAT, 0x85},
+	{mmu.AddrBGP, 0xfc},
+}
+
+// Registered trademark tile drawn by the DMG boot ROM next to the logo.
+var bootTrademark = [8]uint8{0x3c, 0x42, 0xb9, 0xa5, 0xb9, 0xa5, 0x42, 0x3c}
+
+// Skip the boot ROM by putting the Game Boy in the state the boot ROM of a model leaves it in.
+// This should be called after the cartridge is loaded, since some of the state depends on its
+// header. Only the CGB runs cartridges in CGB mode, and it runs cartridges without CGB support in
+// DMG mode without the compatibility palettes.
+func (gb *GameBoy) SkipBoot(model Model) error {
+	state, ok := postBootStates[model]
+	if !ok {
+		return fmt.Errorf("Unknown model: %v", model)
+	}
+
+	// Unmap the boot ROM if there is one.
+	if gb.boot != nil {
+		gb.boot.SetBOOT(0x1)
+	}
+
+	var header *cart.Header
+	if gb.cart != nil {
+		header = gb.cart.Header()
+	}
+	cgb := model == ModelCGB && header != nil && header.CGB()
+	gb.setCGB(cgb)
+
+	// Some boot ROMs leave values that depend on the header.
+	switch {
+	case (model == ModelDMG || model == ModelMGB) && header != nil && header.HeaderChecksum == 0x00:
+		// H and C are only set if the header checksum is nonzero.
+		state.af &= 0xff8f
+	case model == ModelCGB && !cgb:
+		state = cgbDMGModeState(gb.mmu, header, state.counter)
+	}
+
+	// Set up the CPU.
+	gb.cpu.SetRegister16(cpu.RegisterAF, state.af)
+	gb.cpu.SetRegister16(cpu.RegisterBC, state.bc)
+	gb.cpu.SetRegister16(cpu.RegisterDE, state.de)
+	gb.cpu.SetRegister16(cpu.RegisterHL, state.hl)
+	gb.cpu.SetSP(0xfffe)
+	gb.cpu.SetPC(cart.AddrHeader)
+	gb.cpu.SetCounter(state.counter)
+
+	// Set up the IO registers.
+	for _, reg := range postBootIO {
+		gb.mmu.Write(reg.addr, reg.v)
+	}
+
+	// Writing LCDC turns the LCD on, but the boot ROM has left it running for many frames.
+	gb.ppu.SetLCDRunning()
+
+	// Set up the PPU. The CGB boot ROM sets every background color to white, and the DMG boot ROMs
+	// leave the logo in VRAM.
+	if cgb {
+		gb.mmu.Write(mmu.AddrBCPS, 0x80)
+		for i := 0; i < 32; i++ {
+			gb.mmu.Write(mmu.AddrBCPD, 0xff)
+			gb.mmu.Write(mmu.AddrBCPD, 0x7f)
+		}
+	} else if model != ModelCGB {
+		gb.loadBootLogo()
+	}
+
+	return nil
+}
+
+// Get the CPU state the CGB boot ROM leaves when running a cartridge in DMG mode. B holds the sum
+// of the title bytes for cartridges licensed by Nintendo, and HL depends on B.
+func cgbDMGModeState(m *mmu.MMU, header *cart.Header, counter uint16) postBootState {
+	b := uint8(0)
+	if header != nil && header.Licensee() == "01" {
+		for addr := uint16(cart.AddrTitle); addr < cart.AddrNewLicensee; addr++ {
+			b += m.Read(addr)
+		}
+	}
+	hl := uint16(0x007c)
+	if b == 0x43 || b == 0x58 {
+		hl = 0x991a
+	}
+	return postBootState{0x1180, uint16(b) << 8, 0x0008, hl, counter}
+}
+
+// Load the logo from the cartridge header into VRAM, as the DMG boot ROM does. Each nibble of the
+// logo becomes two rows of a tile, with each bit doubled horizontally.
+func (gb *GameBoy) loadBootLogo() {
+	addr := uint16(mmu.AddrVRAM + 0x10)
+	for i := uint16(0); i < 0x30; i++ {
+		v := gb.mmu.Read(cart.AddrLogo + i)
+		for _, nibble := range []uint8{v >> 4, v & 0xf} {
+			row := uint8(0)
+			for bit := 3; bit >= 0; bit-- {
+				row = row<<2 | (nibble>>bit&0x1)*0x3
+			}
+			gb.mmu.Write(addr, row)
+			gb.mmu.Write(addr+2, row)
+			addr += 4
+		}
+	}
+
+	// The trademark tile follows the logo tiles.
+	for _, row := range bootTrademark {
+		gb.mmu.Write(addr, row)
+		addr += 2
+	}
+
+	// Tiles 1 - 12 and 13 - 24 make up the two rows of the logo, followed by the trademark.
+	for i := uint16(0); i < 12; i++ {
+		gb.mmu.Write(mmu.AddrVRAM+0x1904+i, uint8(i+1))
+		gb.mmu.Write(mmu.AddrVRAM+0x1924+i, uint8(i+13))
+	}
+	gb.mmu.Write(mmu.AddrVRAM+0x1910, 0x19)
+}
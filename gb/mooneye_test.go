@@ -0,0 +1,64 @@
+package gb
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/ruiqimao/go-gb-emu/gb/cpu"
+	"github.com/ruiqimao/go-gb-emu/gb/ppu"
+)
+
+// mooneye gb acceptance tests, relative to the acceptance directory.
+var mooneyeTests = []string{
+	"div_timing",
+	"intr_timing",
+	"ppu/hblank_ly_scx_timing-GS",
+	"ppu/intr_1_2_timing-GS",
+	"ppu/intr_2_0_timing",
+	"ppu/intr_2_mode0_timing",
+	"ppu/intr_2_mode0_timing_sprites",
+	"ppu/intr_2_mode3_timing",
+	"ppu/intr_2_oam_ok_timing",
+	"ppu/lcdon_timing-GS",
+	"ppu/stat_lyc_onoff",
+	"ppu/vblank_stat_intr-GS",
+}
+
+// Frames to run a mooneye test for before giving up.
+const mooneyeFrames = 600
+
+// Registers set by a mooneye test once it finishes. Passing tests load the Fibonacci numbers, and
+// failing tests load 0x42 into every register.
+var (
+	mooneyeRegisters = []cpu.Register{
+		cpu.RegisterB, cpu.RegisterC, cpu.RegisterD, cpu.RegisterE, cpu.RegisterH, cpu.RegisterL,
+	}
+	mooneyePass = []uint8{3, 5, 8, 13, 21, 34}
+	mooneyeFail = []uint8{0x42, 0x42, 0x42, 0x42, 0x42, 0x42}
+)
+
+func TestMooneye(t *testing.T) {
+	for _, name := range mooneyeTests {
+		name := name
+		t.Run(name, func(t *testing.T) {
+			gb := newTestROMGameBoy(t, "mooneye/acceptance/"+name+".gb")
+			for i := 0; i < mooneyeFrames; i++ {
+				err := gb.RunCycles(ppu.FrameClocks)
+				if err != nil {
+					t.Fatal(err)
+				}
+				regs := make([]uint8, len(mooneyeRegisters))
+				for j, reg := range mooneyeRegisters {
+					regs[j] = gb.cpu.GetRegister(reg)
+				}
+				if bytes.Equal(regs, mooneyePass) {
+					return
+				}
+				if bytes.Equal(regs, mooneyeFail) {
+					t.Fatal("Test failed")
+				}
+			}
+			t.Fatalf("Test did not finish after %v frames", mooneyeFrames)
+		})
+	}
+}
@@ -0,0 +1,55 @@
+package ppu
+
+// VRAM constants.
+const (
+	VRAMBankSize = 0x2000
+)
+
+// Set whether the PPU is in CGB mode, which enables the second VRAM bank and the CGB registers.
+func (p *PPU) SetCGB(cgb bool) {
+	p.cgb = cgb
+	p.vbk = 0x0
+	p.opri = 0x0
+	p.bcps = 0x0
+	p.ocps = 0x0
+	if !cgb {
+		// DMG mode always prioritizes objects by X coordinate.
+		p.opri = 0x1
+	}
+}
+
+// Get whether the PPU is in CGB mode.
+func (p *PPU) CGB() bool {
+	return p.cgb
+}
+
+// Get the VBK register.
+func (p *PPU) VBK() uint8 {
+	if !p.cgb {
+		return 0xff
+	}
+	return p.vbk | 0xfe
+}
+
+// Set the VBK register.
+func (p *PPU) SetVBK(v uint8) {
+	if p.cgb {
+		p.vbk = v & 0x1
+	}
+}
+
+// Get the OPRI register.
+func (p *PPU) OPRI() uint8 {
+	if !p.cgb {
+		return 0xff
+	}
+	return p.opri | 0xfe
+}
+
+// Set the OPRI register. Bit 0 selects whether objects are prioritized by X coordinate instead of
+// by their position in OAM.
+func (p *PPU) SetOPRI(v uint8) {
+	if p.cgb {
+		p.opri = v & 0x1
+	}
+}
@@ -1,6 +1,8 @@
 package ppu
 
 import (
+	"encoding/binary"
+
 	"github.com/ruiqimao/go-gb-emu/utils"
 )
 
@@ -36,32 +38,51 @@ const (
 
 // Timing constants.
 const (
-	OAMClocks = 80
-	HClocks   = 456
-	VLines    = 154
+	OAMClocks      = 80
+	HClocks        = 456
+	VLines         = 154
+	LYChangeClocks = 4 // Clocks at the start of a line before LY can be compared with LYC.
+	FrameClocks    = HClocks * VLines
+)
+
+// Colors shown while the LCD is off, which are white in both frame formats.
+const (
+	ShadeOff  = 0x00
+	RGB555Off = 0x7fff
 )
 
 // Buffer constants.
 const (
 	FrameWidth  = 160
 	FrameHeight = 144
+
+	ShadeBytes  = 1 // Bytes per pixel in a DMG frame.
+	RGB555Bytes = 2 // Bytes per pixel in a CGB frame.
 )
 
-// Resets the PPU.
+// Resets the PPU when the LCD is turned off.
 func (p *PPU) reset() {
 	p.sc = 0
 	p.ly = 0
+	p.mode = ModeHBlank
+	p.oamCache = nil
+	p.resetWindow()
 }
 
 // Push a frame to the MMU.
 func (p *PPU) pushFrame() {
-	// Make a copy of the frame.
-	frame := make([]byte, len(p.frame))
-	copy(frame, p.frame[:])
-
-	// If the LCD is off, send nil instead.
-	if !p.lcdPower {
-		frame = nil
+	// Make a copy of the frame in the output format for the current mode.
+	var frame []byte
+	if p.cgb {
+		frame = make([]byte, len(p.frame)*RGB555Bytes)
+		for i, color := range p.frame {
+			binary.LittleEndian.PutUint16(frame[i*RGB555Bytes:], color)
+		}
+	} else {
+		frame = make([]byte, len(p.frame)*ShadeBytes)
+		for i, shade := range p.frame {
+			frame[i] = uint8(shade)
+		}
 	}
 
 	// Try to push the frame. If the channel is full, drop the frame.
@@ -71,18 +92,44 @@ func (p *PPU) pushFrame() {
 	}
 }
 
+// Fill the frame with the color shown when the LCD is off.
+func (p *PPU) clearFrame() {
+	color := uint16(ShadeOff)
+	if p.cgb {
+		color = RGB555Off
+	}
+	for i := range p.frame {
+		p.frame[i] = color
+	}
+}
+
+// Do a step while the LCD is off. A blank frame is pushed every frame's worth of clocks, so frames
+// keep coming at the same rate.
+func (p *PPU) stepLCDOff() {
+	p.offClocks++
+	if p.offClocks < FrameClocks {
+		return
+	}
+	p.offClocks = 0
+	p.clearFrame()
+	p.pushFrame()
+}
+
 // Update the STAT signal.
 func (p *PPU) updateSTAT() {
 	statSig := uint8(0)
 
 	// Calculate new signal.
-	if p.lycCheck && p.ly == p.lyc {
+	if p.lycCheck && p.lycMatch {
 		statSig = 0x1
 	}
 	if p.hblCheck && p.mode == ModeHBlank {
 		statSig = 0x1
 	}
-	if (p.vblCheck || p.oamCheck) && p.mode == ModeVBlank { // Possible bug in Game Boy hardware.
+	if p.vblCheck && p.mode == ModeVBlank {
+		statSig = 0x1
+	}
+	if p.oamCheck && p.ly == FrameHeight && p.sc == 0 { // The OAM check also fires as VBlank starts.
 		statSig = 0x1
 	}
 	if p.oamCheck && p.mode == ModeOAM {
@@ -120,13 +167,32 @@ func (p *PPU) SetLCDC(v uint8) {
 	p.tileset = Tileset(utils.GetBit(v, FlagTileset))
 	p.winEnable = utils.GetBit(v, FlagWindowEnable)
 	p.winMap = TileMap(utils.GetBit(v, FlagWindowTileMap))
-	p.lcdPower = utils.GetBit(v, FlagLCDPower)
+
+	// Turning the LCD off resets the PPU, and turning it back on starts from the first line. The
+	// position in the frame is kept while the LCD is off so that blank frames stay in step.
+	lcdPower := utils.GetBit(v, FlagLCDPower)
+	if p.lcdPower && !lcdPower {
+		p.offClocks = uint32(p.ly)*HClocks + uint32(p.sc)
+		p.reset()
+	}
+	if !p.lcdPower && lcdPower {
+		p.lcdStarting = true
+		p.skipFrame = true
+	}
+	p.lcdPower = lcdPower
+}
+
+// Set the LCD as having been on for a while, as the boot ROM leaves it. The first line and frame
+// after this are drawn normally instead of as if the LCD was just turned on.
+func (p *PPU) SetLCDRunning() {
+	p.lcdStarting = false
+	p.skipFrame = false
 }
 
 // Get the STAT register.
 func (p *PPU) STAT() uint8 {
 	stat := uint8(p.mode) & 0x3
-	stat = utils.SetBit(stat, FlagLYCComparison, p.ly == p.lyc)
+	stat = utils.SetBit(stat, FlagLYCComparison, p.lycMatch)
 	stat = utils.SetBit(stat, FlagHBLCheck, p.hblCheck)
 	stat = utils.SetBit(stat, FlagVBLCheck, p.vblCheck)
 	stat = utils.SetBit(stat, FlagOAMCheck, p.oamCheck)
@@ -143,11 +209,24 @@ func (p *PPU) SetSTAT(v uint8) {
 	p.lycCheck = utils.GetBit(v, FlagLYCCheck)
 }
 
-// Get the LY register.
+// Get the LY register. On the last line, LY changes to 0 early.
 func (p *PPU) LY() uint8 {
+	if p.ly == VLines-1 && p.sc >= LYChangeClocks {
+		return 0
+	}
 	return p.ly
 }
 
+// Update the LY=LYC comparison. LY can't be compared for the first clocks of each line while it is
+// changing, except on the last line and the first line, since LY has already changed to 0.
+func (p *PPU) updateLYC() {
+	if p.sc < LYChangeClocks && p.ly != 0 && p.ly != VLines-1 {
+		p.lycMatch = false
+		return
+	}
+	p.lycMatch = p.LY() == p.lyc
+}
+
 // Set the LY register. This does nothing, as LY is not writable.
 func (p *PPU) SetLY(v uint8) {
 	// LY is read-only.
@@ -172,3 +251,9 @@ func (p *PPU) VRAMAccessible() bool {
 func (p *PPU) OAMAccessible() bool {
 	return p.mode != ModeOAM && p.mode != ModeTransfer
 }
+
+// Get whether the PPU is in the HBlank after drawing a line. The first line after the LCD is turned
+// on reports HBlank before drawing, which does not count.
+func (p *PPU) InHBlank() bool {
+	return p.lcdPower && p.mode == ModeHBlank && p.ly < FrameHeight && !p.lcdStarting
+}
@@ -22,6 +22,6 @@ func (p *PPU) TileData(id uint8, offset uint8, sprite bool) uint8 {
 	return p.tileData(id, offset, sprite)
 }
 
-func (p *PPU) Resolve(px Pixel) uint8 {
+func (p *PPU) Resolve(px Pixel) uint16 {
 	return p.resolve(px)
 }
@@ -1,15 +1,21 @@
 package ppu
 
-// Fetcher states.
+import (
+	"github.com/ruiqimao/go-gb-emu/utils"
+)
+
+// Fetcher states. Each state except FetcherPush takes 2 clocks.
+const (
+	FetcherTile  = 0
+	FetcherData0 = 1
+	FetcherData1 = 2
+	FetcherPush  = 3
+)
+
+// Fetcher timing constants.
 const (
-	FetcherTile        = 0
-	FetcherData0       = 1
-	FetcherData1       = 2
-	FetcherIdle        = 3
-	FetcherSpriteTile  = 4
-	FetcherSpriteData0 = 5
-	FetcherSpriteData1 = 6
-	FetcherSpriteIdle  = 7
+	FetcherStateClocks = 2
+	SpriteFetchClocks  = 6
 )
 
 // A Pixel contains color data and its source.
@@ -17,41 +23,51 @@ type Pixel struct {
 	data uint8
 
 	bg       bool
-	palette  bool
+	palette  uint8
 	priority bool
+
+	// OAM index of the sprite the pixel is from.
+	index uint8
 }
 
 // The Fetcher retrieves data from VRAM and stores it in the FIFO.
-// This implementation uses the Ultimate Game Boy Talk as a reference. Although the talk is
-// slightly wrong with some regards to the PPU pipeline, it is close enough for an acceptable
-// approximation.
-// TODO: Sprites.
+// The background fetcher takes 2 clocks each to read the tile number and the two bytes of tile
+// data, and then waits for the background FIFO to be empty before pushing 8 pixels into it. The
+// first fetch of each line is thrown away. Fetching a sprite waits for the background fetcher to
+// have its data ready, and then takes 6 clocks, during which no pixels are shifted out.
 type Fetcher struct {
 	ppu *PPU
 
 	// Background and sprite FIFOs.
-	fifo []Pixel
+	fifo       []Pixel
+	spriteFIFO []Pixel
 
 	// Fetcher state.
 	state uint8
+	cycle uint8
 	bgMap uint16
 
 	tileX       uint8
 	tileY       uint8
 	tileDiscard uint8
 	tileOffset  uint8
+	skipFetch   bool
 
 	sprite           Sprite
+	spritePending    bool
+	spriteClocks     uint8
 	spriteTileOffset uint8
 	spriteTileN      uint8
+	spriteDiscard    uint8
 
 	tileN uint8
+	attr  uint8
 	data0 uint8
 	data1 uint8
 }
 
-func NewPixel(data uint8, bg bool, palette bool, priority bool) Pixel {
-	return Pixel{data, bg, palette, priority}
+func NewPixel(data uint8, bg bool, palette uint8, priority bool) Pixel {
+	return Pixel{data, bg, palette, priority, 0}
 }
 
 func NewFetcher(ppu *PPU) *Fetcher {
@@ -61,7 +77,17 @@ func NewFetcher(ppu *PPU) *Fetcher {
 	return f
 }
 
-// Reset the fetcher.
+// Start the fetcher on a new line. The first tile fetched on a line is thrown away.
+func (f *Fetcher) Start(x uint8, y uint8, mapAddr uint16) {
+	f.spriteFIFO = nil
+	f.spritePending = false
+	f.spriteClocks = 0
+	f.Reset(x, y, mapAddr)
+	f.skipFetch = true
+}
+
+// Reset the fetcher to a position in a tile map. The sprite FIFO is kept, since the fetcher is
+// reset partway through a line when the window starts.
 func (f *Fetcher) Reset(x uint8, y uint8, mapAddr uint16) {
 	f.fifo = nil
 
@@ -70,7 +96,7 @@ func (f *Fetcher) Reset(x uint8, y uint8, mapAddr uint16) {
 	f.tileY = y / 8
 
 	// Use the lower 3 bits to determine how many pixels to discard.
-	f.tileDiscard = x & 0x03
+	f.tileDiscard = x & 0x07
 
 	// Use the y position modulo 8 to determine the vertical offset for the pixel.
 	f.tileOffset = (y % 8) * 2
@@ -80,18 +106,21 @@ func (f *Fetcher) Reset(x uint8, y uint8, mapAddr uint16) {
 
 	// Reset the state.
 	f.state = FetcherTile
+	f.cycle = 0
+	f.skipFetch = false
 }
 
-// Pop an element off the FIFO. Returns the color and whether the pop was successful.
-func (f *Fetcher) Pop() (uint8, bool) {
-	// FIFO must have at least 8 elements.
-	if len(f.fifo) <= 8 {
-		return 0, false
+// Pop an element off the FIFOs. Returns the pixel that ends up on screen and whether the pop was
+// successful. Discarding a pixel for fine scrolling takes a clock, but is not a successful pop.
+func (f *Fetcher) Pop() (Pixel, bool) {
+	// Pixels aren't shifted out while a sprite is being fetched.
+	if f.fetchingSprite() {
+		return Pixel{}, false
 	}
 
-	// The fetcher must not be loading a sprite.
-	if f.state > FetcherIdle {
-		return 0, false
+	// The FIFO must not be empty.
+	if len(f.fifo) == 0 {
+		return Pixel{}, false
 	}
 
 	// Pop a pixel off.
@@ -101,111 +130,159 @@ func (f *Fetcher) Pop() (uint8, bool) {
 	// Discard the pixel if needed.
 	if f.tileDiscard > 0 {
 		f.tileDiscard--
-		return 0, false
+		return Pixel{}, false
 	}
 
-	// Return the evaluated color.
-	return f.ppu.resolve(pixel), true
+	// Pop a sprite pixel off if there is one, and mix it with the background pixel.
+	if len(f.spriteFIFO) > 0 {
+		spPx := f.spriteFIFO[0]
+		f.spriteFIFO = f.spriteFIFO[1:]
+		pixel = f.mix(pixel, spPx)
+	}
+
+	return pixel, true
 }
 
 // Push a sprite into the fetcher. Returns if it was successful.
 func (f *Fetcher) PushSprite(sprite Sprite) bool {
 	// The fetcher must not be already loading a sprite.
-	if f.state > FetcherIdle {
+	if f.fetchingSprite() {
 		return false
 	}
 
-	// Set up the fetcher to fetch the sprite.
+	// Set up the fetcher to fetch the sprite once the background fetcher is ready.
 	f.sprite = sprite
-	f.spriteTileN = sprite.tileN
-	f.spriteTileOffset = (f.ppu.ly + 2*f.ppu.spriteHeight() - sprite.posY) * 2
-	f.state = FetcherSpriteTile
+	f.spritePending = true
 
-	// Check if the sprite is flipped vertically.
+	// Sprites that start left of the current position, such as those partly off the left edge of
+	// the screen, only load their remaining pixels.
+	f.spriteDiscard = 0
+	if start := int(sprite.posX) - 8; start < int(f.ppu.lx) {
+		f.spriteDiscard = uint8(int(f.ppu.lx) - start)
+	}
+
+	// Find the row of the sprite to fetch, checking if the sprite is flipped vertically.
+	height := f.ppu.spriteHeight()
+	row := f.ppu.ly + 16 - sprite.posY
 	if f.sprite.flipY {
-		f.spriteTileOffset = f.ppu.spriteHeight()*2 - 1 - f.spriteTileOffset
+		row = height - 1 - row
 	}
 
-	// If the y offset is too tall, change the tile number.
-	if f.spriteTileOffset >= 16 {
-		f.spriteTileN += 32
-		f.spriteTileOffset -= 16
+	// Large sprites are made of two tiles, with the top one having an even tile number.
+	f.spriteTileN = sprite.tileN
+	if height == 16 {
+		f.spriteTileN &= 0xfe
+	}
+	if row >= 8 {
+		f.spriteTileN++
+		row -= 8
 	}
+	f.spriteTileOffset = row * 2
 
 	return true
 }
 
-// Do one step of the fetcher.
-// The fetcher runs at half speed, so this should be run every other clock.
+// Get whether the fetcher is loading a sprite, or waiting to.
+func (f *Fetcher) fetchingSprite() bool {
+	return f.spritePending || f.spriteClocks > 0
+}
+
+// Do one step of the fetcher. Consumes 1 clock.
 func (f *Fetcher) Step() {
+	// Continue fetching a sprite.
+	if f.spriteClocks > 0 {
+		f.spriteClocks--
+		if f.spriteClocks == 0 {
+			f.loadSprite()
+		}
+		return
+	}
+
+	f.stepBg()
+
+	// Start fetching a pending sprite once the background fetcher has its data ready. The sprite
+	// fetch starts on the same clock.
+	if f.spritePending && f.state == FetcherPush {
+		f.spritePending = false
+		f.spriteClocks = SpriteFetchClocks - 1
+	}
+}
+
+// Do one step of the background fetcher.
+func (f *Fetcher) stepBg() {
+	// Each state but the last takes 2 clocks, and acts on the second.
+	if f.state != FetcherPush {
+		f.cycle++
+		if f.cycle < FetcherStateClocks {
+			return
+		}
+		f.cycle = 0
+	}
+
 	switch f.state {
 
 	case FetcherTile:
 		// Fetch the tile number.
-		f.tileN = f.ppu.vram[f.bgMap+uint16(f.tileY)*32+uint16(f.tileX)]
+		mapAddr := f.bgMap + uint16(f.tileY)*32 + uint16(f.tileX)
+		f.tileN = f.ppu.vram[mapAddr]
+
+		// In CGB mode, the tile attributes are at the same address in VRAM bank 1.
+		f.attr = 0
+		if f.ppu.cgb {
+			f.attr = f.ppu.vram[VRAMBankSize+mapAddr]
+		}
 		f.state = FetcherData0
 
 	case FetcherData0:
 		// Fetch the first byte of data.
-		f.data0 = f.ppu.tileData(f.tileN, f.tileOffset, false)
+		f.data0 = f.ppu.bankTileData(f.attrBank(), f.tileN, f.attrTileOffset(), false)
 		f.state = FetcherData1
 
 	case FetcherData1:
 		// Fetch the second byte of data.
-		f.data1 = f.ppu.tileData(f.tileN, f.tileOffset+1, false)
+		f.data1 = f.ppu.bankTileData(f.attrBank(), f.tileN, f.attrTileOffset()+1, false)
 
 		// Try to load the data into the FIFO.
 		if f.loadBg() {
 			f.state = FetcherTile
 		} else {
-			f.state = FetcherIdle
+			f.state = FetcherPush
 		}
 
-	case FetcherIdle:
+	case FetcherPush:
 		// Try to load the data into the FIFO.
 		if f.loadBg() {
 			f.state = FetcherTile
 		}
 
-	case FetcherSpriteTile:
-		// Fetch the tile number.
-		f.tileN = f.spriteTileN
-		f.state = FetcherSpriteData0
-
-	case FetcherSpriteData0:
-		// Fetch the first byte of data.
-		f.data0 = f.ppu.tileData(f.spriteTileN, f.spriteTileOffset, true)
-		f.state = FetcherSpriteData1
-
-	case FetcherSpriteData1:
-		// Fetch the second byte of data.
-		f.data1 = f.ppu.tileData(f.spriteTileN, f.spriteTileOffset+1, false)
-		f.state = FetcherSpriteIdle
-
-	case FetcherSpriteIdle:
-		// Try to load the data into the FIFO.
-		if f.loadSprite() {
-			// Go back to normal operation.
-			f.state = FetcherTile
-		}
-
 	}
 }
 
 // Try to load data into the background FIFO. Returns if successful.
 func (f *Fetcher) loadBg() bool {
-	// FIFO can have at most 8 elements to load.
-	if len(f.fifo) > 8 {
+	// FIFO must be empty to load.
+	if len(f.fifo) > 0 {
 		return false
 	}
 
-	// Load each pixel. Load from MSB to LSB, since MSB is to the left.
-	for i := 7; i >= 0; i-- {
-		lo := (f.data0 >> i) & 0x1
-		hi := (f.data1 >> i) & 0x1
+	// Throw away the first fetch of the line.
+	if f.skipFetch {
+		f.skipFetch = false
+		return true
+	}
 
-		data := lo | hi<<1
-		f.fifo = append(f.fifo, NewPixel(data, true, false, false))
+	// Load each pixel from left to right. If the tile is flipped horizontally, load from right to
+	// left.
+	palette := f.attr & 0x7
+	priority := utils.GetBit(f.attr, FlagAttrPriority)
+	for i := 0; i < 8; i++ {
+		x := i
+		if utils.GetBit(f.attr, FlagAttrFlipX) {
+			x = 7 - i
+		}
+
+		data := TilePixel(f.data0, f.data1, x)
+		f.fifo = append(f.fifo, NewPixel(data, true, palette, priority))
 	}
 
 	// Increment the tile X position.
@@ -214,28 +291,74 @@ func (f *Fetcher) loadBg() bool {
 	return true
 }
 
-// Try to load sprite data and mix it into the FIFO.
-func (f *Fetcher) loadSprite() bool {
-	// Load each pixel.
-	for i := 7; i >= 0; i-- {
-		// If the sprite is flipped horizontally, load from LSB to MSB. Otherwise, load from MSB to LSB.
+// Load sprite data and merge it into the sprite FIFO.
+func (f *Fetcher) loadSprite() {
+	data0 := f.ppu.bankTileData(f.sprite.bank, f.spriteTileN, f.spriteTileOffset, true)
+	data1 := f.ppu.bankTileData(f.sprite.bank, f.spriteTileN, f.spriteTileOffset+1, true)
+
+	// Load each pixel. If the sprite is flipped horizontally, load from right to left.
+	for i := int(f.spriteDiscard); i < 8; i++ {
 		x := i
 		if f.sprite.flipX {
 			x = 7 - i
 		}
 
-		lo := (f.data0 >> x) & 0x1
-		hi := (f.data1 >> x) & 0x1
+		data := TilePixel(data0, data1, x)
+		px := NewPixel(data, false, f.sprite.palette, f.sprite.priority)
+		px.index = f.sprite.index
+		f.merge(i-int(f.spriteDiscard), px)
+	}
+}
 
-		data := lo | hi<<1
-		f.mix(7-i, NewPixel(data, false, f.sprite.palette, f.sprite.priority))
+// Merge a sprite pixel into the sprite FIFO. Sprites already in the FIFO were at a lower X
+// position, or at the same position and earlier in OAM, so they are drawn over the new sprite
+// unless they are transparent. When objects are prioritized by OAM position instead, a sprite
+// earlier in OAM is drawn over the sprite already in the FIFO.
+func (f *Fetcher) merge(i int, spPx Pixel) {
+	if i >= len(f.spriteFIFO) {
+		f.spriteFIFO = append(f.spriteFIFO, spPx)
+		return
 	}
 
-	return true
+	if spPx.data == 0 {
+		return
+	}
+	old := f.spriteFIFO[i]
+	if old.data == 0 || (f.ppu.opri == 0 && spPx.index < old.index) {
+		f.spriteFIFO[i] = spPx
+	}
+}
+
+// Get the VRAM bank the current background tile's data is in.
+func (f *Fetcher) attrBank() uint8 {
+	return (f.attr >> FlagAttrBank) & 0x1
 }
 
-// Mix a sprite pixel into the FIFO.
-func (f *Fetcher) mix(i int, spPx Pixel) {
-	// TODO.
-	f.fifo[i] = spPx
+// Get the offset into the current background tile's data, taking vertical flipping into account.
+func (f *Fetcher) attrTileOffset() uint8 {
+	if utils.GetBit(f.attr, FlagAttrFlipY) {
+		return 14 - f.tileOffset
+	}
+	return f.tileOffset
+}
+
+// Mix a sprite pixel with a background pixel, and return the pixel that ends up on screen.
+func (f *Fetcher) mix(bgPx Pixel, spPx Pixel) Pixel {
+	// Sprites can be turned off, and color 0 of a sprite is transparent.
+	if !f.ppu.spritesEnable || spPx.data == 0 {
+		return bgPx
+	}
+
+	// In DMG mode, a disabled background is blank, so sprites are always drawn over it. In CGB
+	// mode, clearing the same bit makes sprites always drawn over the background instead.
+	if !f.ppu.bgEnable {
+		return spPx
+	}
+
+	// A sprite or background tile with its priority bit set is drawn behind background colors
+	// 1-3. The background tile priority bit only exists in CGB mode.
+	if (spPx.priority || bgPx.priority) && bgPx.data != 0 {
+		return bgPx
+	}
+	return spPx
 }
@@ -9,11 +9,12 @@ const (
 // MMU interface.
 type MMU interface {
 	RequestInterrupt(int)
+	HBlank()
 }
 
-// Reads from the VRAM. The address is in the range [0x0000, 0x4000).
+// Reads from the selected VRAM bank. The address is in the range [0x0000, 0x2000).
 func (p *PPU) ReadVRAM(addr uint16) uint8 {
-	return p.vram[addr]
+	return p.vram[uint16(p.vbk)*VRAMBankSize+addr]
 }
 
 // Reads from the OAM. The address is in the range [0x0000, 0x0100).
@@ -21,9 +22,9 @@ func (p *PPU) ReadOAM(addr uint16) uint8 {
 	return p.oam[addr]
 }
 
-// Writes to the VRAM. The address is in the range [0x0000, 0x4000).
+// Writes to the selected VRAM bank. The address is in the range [0x0000, 0x2000).
 func (p *PPU) WriteVRAM(addr uint16, v uint8) {
-	p.vram[addr] = v
+	p.vram[uint16(p.vbk)*VRAMBankSize+addr] = v
 }
 
 // Writes to the OAM. The address is in the range [0x0000, 0x0100).
@@ -38,6 +39,13 @@ func (p *PPU) interruptVBlank() {
 	}
 }
 
+// Signal the start of HBlank on a visible line.
+func (p *PPU) signalHBlank() {
+	if p.mmu != nil {
+		p.mmu.HBlank()
+	}
+}
+
 // Request a STAT interrupt.
 func (p *PPU) interruptSTAT() {
 	if p.mmu != nil {
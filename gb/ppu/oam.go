@@ -16,8 +16,9 @@ const (
 	MaxSpritesPerScanline = 10
 )
 
-// Flags for sprites.
+// Flags for sprites. In CGB mode, the palette is in the bits below FlagCGBBank instead.
 const (
+	FlagCGBBank  = 3
 	FlagPalette  = 4
 	FlagFlipX    = 5
 	FlagFlipY    = 6
@@ -29,7 +30,9 @@ type Sprite struct {
 	posY     uint8
 	posX     uint8
 	tileN    uint8
-	palette  bool
+	index    uint8
+	palette  uint8
+	bank     uint8
 	flipX    bool
 	flipY    bool
 	priority bool
@@ -41,11 +44,21 @@ func (p *PPU) newSprite(addr uint16) Sprite {
 	tileN := p.oam[addr+0x2]
 	flags := p.oam[addr+0x3]
 
+	// CGB sprites pick one of 8 palettes and can take their tile from either VRAM bank.
+	palette := (flags >> FlagPalette) & 0x1
+	bank := uint8(0)
+	if p.cgb {
+		palette = flags & 0x7
+		bank = (flags >> FlagCGBBank) & 0x1
+	}
+
 	return Sprite{
 		posY:     posY,
 		posX:     posX,
 		tileN:    tileN,
-		palette:  utils.GetBit(flags, FlagPalette),
+		index:    uint8(addr / 4),
+		palette:  palette,
+		bank:     bank,
 		flipX:    utils.GetBit(flags, FlagFlipX),
 		flipY:    utils.GetBit(flags, FlagFlipY),
 		priority: utils.GetBit(flags, FlagPriority),
@@ -73,13 +86,13 @@ func (p *PPU) stepOAMSearch() {
 	// Decode the next entry into a Sprite.
 	sprite := p.newSprite(p.sc * 2)
 
-	// Determine if the sprite is in the current scanline.
-	inFrame := sprite.posX != 0
+	// Determine if the sprite is in the current scanline. Sprites are selected by their Y position
+	// only, so hidden sprites at X = 0 still count towards the limit and still take time to fetch.
 	inUpperBound := p.ly+0x10 >= sprite.posY
 	inLowerBound := p.ly+0x10 < sprite.posY+p.spriteHeight()
 
-	// If the sprite is visible, add it to the OAM cache.
-	if inFrame && inUpperBound && inLowerBound {
+	// If the sprite is on the line, add it to the OAM cache.
+	if inUpperBound && inLowerBound {
 		p.oamCache = append(p.oamCache, sprite)
 	}
 }
@@ -0,0 +1,95 @@
+package ppu
+
+import (
+	"github.com/ruiqimao/go-gb-emu/utils"
+)
+
+// CGB palette constants.
+const (
+	PaletteRAMSize    = 0x40 // 8 palettes of 4 colors, 2 bytes per color.
+	FlagAutoIncrement = 7    // Palette spec bit that increments the index after each data write.
+)
+
+// Get the color of a pixel from CGB palette RAM as 15-bit RGB. Colors are stored little endian
+// with red in the lowest 5 bits, then green, then blue.
+func paletteColor(ram *[PaletteRAMSize]uint8, palette uint8, data uint8) uint16 {
+	i := uint16(palette&0x7)*8 + uint16(data)*2
+	return (uint16(ram[i]) | uint16(ram[i+1])<<8) & 0x7fff
+}
+
+// Read from palette RAM through a palette data register. Palette RAM can't be read while the PPU
+// is transferring pixels.
+func (p *PPU) readPalette(ram *[PaletteRAMSize]uint8, spec uint8) uint8 {
+	if !p.cgb || p.mode == ModeTransfer {
+		return 0xff
+	}
+	return ram[spec&0x3f]
+}
+
+// Write to palette RAM through a palette data register and return the new spec. Writes while the
+// PPU is transferring pixels are dropped, but still increment the index.
+func (p *PPU) writePalette(ram *[PaletteRAMSize]uint8, spec uint8, v uint8) uint8 {
+	if p.mode != ModeTransfer {
+		ram[spec&0x3f] = v
+	}
+	if utils.GetBit(spec, FlagAutoIncrement) {
+		spec = spec&0x80 | (spec+1)&0x3f
+	}
+	return spec
+}
+
+// Get the BCPS register.
+func (p *PPU) BCPS() uint8 {
+	if !p.cgb {
+		return 0xff
+	}
+	return p.bcps | 0x40
+}
+
+// Set the BCPS register. Bits 0-5 select a byte in background palette RAM, and bit 7 enables
+// auto-increment.
+func (p *PPU) SetBCPS(v uint8) {
+	if p.cgb {
+		p.bcps = v & 0xbf
+	}
+}
+
+// Get the BCPD register.
+func (p *PPU) BCPD() uint8 {
+	return p.readPalette(&p.bgPalettes, p.bcps)
+}
+
+// Set the BCPD register.
+func (p *PPU) SetBCPD(v uint8) {
+	if p.cgb {
+		p.bcps = p.writePalette(&p.bgPalettes, p.bcps, v)
+	}
+}
+
+// Get the OCPS register.
+func (p *PPU) OCPS() uint8 {
+	if !p.cgb {
+		return 0xff
+	}
+	return p.ocps | 0x40
+}
+
+// Set the OCPS register. Bits 0-5 select a byte in object palette RAM, and bit 7 enables
+// auto-increment.
+func (p *PPU) SetOCPS(v uint8) {
+	if p.cgb {
+		p.ocps = v & 0xbf
+	}
+}
+
+// Get the OCPD register.
+func (p *PPU) OCPD() uint8 {
+	return p.readPalette(&p.objPalettes, p.ocps)
+}
+
+// Set the OCPD register.
+func (p *PPU) SetOCPD(v uint8) {
+	if p.cgb {
+		p.ocps = p.writePalette(&p.objPalettes, p.ocps, v)
+	}
+}
@@ -35,10 +35,33 @@ type PPU struct {
 	// STAT signal.
 	statSig uint8
 
-	// Memory.
-	vram [0x4000]uint8
+	// Latched result of comparing LY with LYC. This holds its value while the LCD is off.
+	lycMatch bool
+
+	// Whether the LCD was just turned on and is on its first line.
+	lcdStarting bool
+
+	// Whether the LCD was just turned on and its first frame should be blank.
+	skipFrame bool
+
+	// Clocks since the last frame while the LCD is off.
+	offClocks uint32
+
+	// Memory. VRAM holds both banks, and bank 1 is only used in CGB mode.
+	vram [VRAMBankSize * 2]uint8
 	oam  [0x100]uint8
 
+	// CGB mode and registers.
+	cgb  bool
+	vbk  uint8
+	opri uint8
+
+	// CGB palette RAM and the spec registers indexing into it.
+	bgPalettes  [PaletteRAMSize]uint8
+	objPalettes [PaletteRAMSize]uint8
+	bcps        uint8
+	ocps        uint8
+
 	// Scanline counter.
 	sc uint16
 
@@ -48,15 +71,23 @@ type PPU struct {
 	// Pixel transfer state.
 	fetcher *Fetcher
 	lx      uint8
-	frame   [FrameWidth * FrameHeight]uint8
+	frame   [FrameWidth * FrameHeight]uint16
+
+	// Window state. The window has its own line counter, and can only start once LY has matched WY
+	// during the frame.
+	wly       uint8
+	winYMatch bool
+	winActive bool
 
-	// Latest rendered frame.
+	// Latest rendered frame. In DMG mode each pixel is a 1 byte shade in the range [0, 4). In CGB
+	// mode each pixel is 2 bytes of little endian 15-bit RGB.
 	F chan []uint8
 }
 
 func NewPPU() *PPU {
 	p := &PPU{
-		F: make(chan []uint8, 1),
+		opri: 0x1,
+		F:    make(chan []uint8, 1),
 	}
 	p.fetcher = NewFetcher(p)
 	return p
@@ -64,30 +95,44 @@ func NewPPU() *PPU {
 
 // Do a PPU step. Consumes 1 clock.
 func (p *PPU) Step() {
-	// If the LCD is off, reset the PPU.
+	// The PPU is held in reset while the LCD is off, but blank frames are still pushed.
 	if !p.lcdPower {
-		p.reset()
+		p.stepLCDOff()
+		return
 	}
 
-	// Update the mode.
+	// Update the mode. The first line after the LCD is turned on reports HBlank instead of OAM
+	// search.
 	switch {
 	case p.ly < FrameHeight && p.sc == 0:
 		p.mode = ModeOAM
+		if p.lcdStarting {
+			p.mode = ModeHBlank
+		}
+		p.checkWindowY()
 		p.startOAMSearch()
 	case p.ly < FrameHeight && p.sc == OAMClocks:
 		p.mode = ModeTransfer
+		p.lcdStarting = false
 		p.startPixelTransfer()
 	case p.ly == FrameHeight && p.sc == 0:
 		p.mode = ModeVBlank
+		p.resetWindow()
+		if p.skipFrame {
+			// The first frame after the LCD is turned on is not shown.
+			p.skipFrame = false
+			p.clearFrame()
+		}
 		p.pushFrame()
 		p.interruptVBlank()
 	}
 
-	// Update the STAT signal.
+	// Update the LY=LYC comparison and the STAT signal.
+	p.updateLYC()
 	p.updateSTAT()
 
 	// Perform a step of OAM search or pixel transfer.
-	if p.mode == ModeOAM {
+	if p.ly < FrameHeight && p.sc < OAMClocks {
 		p.stepOAMSearch()
 	}
 	if p.mode == ModeTransfer {
@@ -0,0 +1,406 @@
+package ppu
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// Serialized PPU state.
+type ppuState struct {
+	// Registers.
+	SCY  uint8
+	SCX  uint8
+	LY   uint8
+	LYC  uint8
+	BGP  uint8
+	OBP0 uint8
+	OBP1 uint8
+	WX   uint8
+	WY   uint8
+
+	// LCDC flags.
+	LCDPower      bool
+	WinMap        bool
+	WinEnable     bool
+	Tileset       bool
+	BgMap         bool
+	SpriteSize    bool
+	SpritesEnable bool
+	BgEnable      bool
+
+	// STAT flags.
+	Mode     uint8
+	HBLCheck bool
+	VBLCheck bool
+	OAMCheck bool
+	LYCCheck bool
+	StatSig  uint8
+	LYCMatch bool
+
+	// Memory.
+	VRAM [VRAMBankSize * 2]uint8
+	OAM  [0x100]uint8
+
+	// CGB mode and registers.
+	CGB  bool
+	VBK  uint8
+	OPRI uint8
+
+	// CGB palettes.
+	BgPalettes  [PaletteRAMSize]uint8
+	ObjPalettes [PaletteRAMSize]uint8
+	BCPS        uint8
+	OCPS        uint8
+
+	// Scanline and pixel transfer state.
+	SC          uint16
+	LX          uint8
+	Frame       [FrameWidth * FrameHeight]uint16
+	LCDStarting bool
+	SkipFrame   bool
+	OffClocks   uint32
+
+	// Window state.
+	WLY       uint8
+	WinYMatch bool
+	WinActive bool
+
+	// Number of sprites in the OAM cache that follow.
+	OAMCacheLen uint8
+}
+
+// Serialized sprite.
+type spriteState struct {
+	PosY     uint8
+	PosX     uint8
+	TileN    uint8
+	Index    uint8
+	Palette  uint8
+	Bank     uint8
+	FlipX    bool
+	FlipY    bool
+	Priority bool
+}
+
+// Serialized fetcher state.
+type fetcherState struct {
+	State uint8
+	Cycle uint8
+	BgMap uint16
+
+	TileX       uint8
+	TileY       uint8
+	TileDiscard uint8
+	TileOffset  uint8
+	SkipFetch   bool
+
+	Sprite           spriteState
+	SpritePending    bool
+	SpriteClocks     uint8
+	SpriteTileOffset uint8
+	SpriteTileN      uint8
+	SpriteDiscard    uint8
+
+	TileN uint8
+	Attr  uint8
+	Data0 uint8
+	Data1 uint8
+
+	// Number of pixels in the background and sprite FIFOs that follow.
+	FIFOLen       uint8
+	SpriteFIFOLen uint8
+}
+
+// Serialized pixel.
+type pixelState struct {
+	Data     uint8
+	BG       bool
+	Palette  uint8
+	Priority bool
+	Index    uint8
+}
+
+// Maximum number of pixels the FIFOs can hold.
+const (
+	MaxFIFOLen       = 16
+	MaxSpriteFIFOLen = 8
+)
+
+// Write the PPU state.
+func (p *PPU) SaveState(w io.Writer) error {
+	s := ppuState{
+		SCY:  p.scy,
+		SCX:  p.scx,
+		LY:   p.ly,
+		LYC:  p.lyc,
+		BGP:  p.bgp,
+		OBP0: p.obp0,
+		OBP1: p.obp1,
+		WX:   p.wx,
+		WY:   p.wy,
+
+		LCDPower:      p.lcdPower,
+		WinMap:        bool(p.winMap),
+		WinEnable:     p.winEnable,
+		Tileset:       bool(p.tileset),
+		BgMap:         bool(p.bgMap),
+		SpriteSize:    bool(p.spriteSize),
+		SpritesEnable: p.spritesEnable,
+		BgEnable:      p.bgEnable,
+
+		Mode:     uint8(p.mode),
+		HBLCheck: p.hblCheck,
+		VBLCheck: p.vblCheck,
+		OAMCheck: p.oamCheck,
+		LYCCheck: p.lycCheck,
+		StatSig:  p.statSig,
+		LYCMatch: p.lycMatch,
+
+		VRAM: p.vram,
+		OAM:  p.oam,
+
+		CGB:  p.cgb,
+		VBK:  p.vbk,
+		OPRI: p.opri,
+
+		BgPalettes:  p.bgPalettes,
+		ObjPalettes: p.objPalettes,
+		BCPS:        p.bcps,
+		OCPS:        p.ocps,
+
+		SC:          p.sc,
+		LX:          p.lx,
+		Frame:       p.frame,
+		LCDStarting: p.lcdStarting,
+		SkipFrame:   p.skipFrame,
+		OffClocks:   p.offClocks,
+
+		WLY:       p.wly,
+		WinYMatch: p.winYMatch,
+		WinActive: p.winActive,
+
+		OAMCacheLen: uint8(len(p.oamCache)),
+	}
+	err := binary.Write(w, binary.LittleEndian, &s)
+	if err != nil {
+		return err
+	}
+
+	// Write the OAM cache.
+	for _, sprite := range p.oamCache {
+		err = binary.Write(w, binary.LittleEndian, newSpriteState(sprite))
+		if err != nil {
+			return err
+		}
+	}
+
+	return p.fetcher.SaveState(w)
+}
+
+// Read the PPU state.
+func (p *PPU) LoadState(r io.Reader) error {
+	var s ppuState
+	err := binary.Read(r, binary.LittleEndian, &s)
+	if err != nil {
+		return err
+	}
+	if s.OAMCacheLen > MaxSpritesPerScanline {
+		return fmt.Errorf("Improper OAM cache size: %v", s.OAMCacheLen)
+	}
+
+	p.scy = s.SCY
+	p.scx = s.SCX
+	p.ly = s.LY
+	p.lyc = s.LYC
+	p.bgp = s.BGP
+	p.obp0 = s.OBP0
+	p.obp1 = s.OBP1
+	p.wx = s.WX
+	p.wy = s.WY
+
+	p.lcdPower = s.LCDPower
+	p.winMap = TileMap(s.WinMap)
+	p.winEnable = s.WinEnable
+	p.tileset = Tileset(s.Tileset)
+	p.bgMap = TileMap(s.BgMap)
+	p.spriteSize = SpriteSize(s.SpriteSize)
+	p.spritesEnable = s.SpritesEnable
+	p.bgEnable = s.BgEnable
+
+	p.mode = Mode(s.Mode)
+	p.hblCheck = s.HBLCheck
+	p.vblCheck = s.VBLCheck
+	p.oamCheck = s.OAMCheck
+	p.lycCheck = s.LYCCheck
+	p.statSig = s.StatSig
+	p.lycMatch = s.LYCMatch
+
+	p.vram = s.VRAM
+	p.oam = s.OAM
+
+	p.cgb = s.CGB
+	p.vbk = s.VBK & 0x1
+	p.opri = s.OPRI & 0x1
+
+	p.bgPalettes = s.BgPalettes
+	p.objPalettes = s.ObjPalettes
+	p.bcps = s.BCPS & 0xbf
+	p.ocps = s.OCPS & 0xbf
+
+	p.sc = s.SC
+	p.lx = s.LX
+	p.frame = s.Frame
+	p.lcdStarting = s.LCDStarting
+	p.skipFrame = s.SkipFrame
+	p.offClocks = s.OffClocks % FrameClocks
+
+	p.wly = s.WLY
+	p.winYMatch = s.WinYMatch
+	p.winActive = s.WinActive
+
+	// Read the OAM cache.
+	p.oamCache = nil
+	for i := 0; i < int(s.OAMCacheLen); i++ {
+		var sprite spriteState
+		err = binary.Read(r, binary.LittleEndian, &sprite)
+		if err != nil {
+			return err
+		}
+		p.oamCache = append(p.oamCache, sprite.sprite())
+	}
+
+	return p.fetcher.LoadState(r)
+}
+
+// Write the fetcher state.
+func (f *Fetcher) SaveState(w io.Writer) error {
+	s := fetcherState{
+		State: f.state,
+		Cycle: f.cycle,
+		BgMap: f.bgMap,
+
+		TileX:       f.tileX,
+		TileY:       f.tileY,
+		TileDiscard: f.tileDiscard,
+		TileOffset:  f.tileOffset,
+		SkipFetch:   f.skipFetch,
+
+		Sprite:           newSpriteState(f.sprite),
+		SpritePending:    f.spritePending,
+		SpriteClocks:     f.spriteClocks,
+		SpriteTileOffset: f.spriteTileOffset,
+		SpriteTileN:      f.spriteTileN,
+		SpriteDiscard:    f.spriteDiscard,
+
+		TileN: f.tileN,
+		Attr:  f.attr,
+		Data0: f.data0,
+		Data1: f.data1,
+
+		FIFOLen:       uint8(len(f.fifo)),
+		SpriteFIFOLen: uint8(len(f.spriteFIFO)),
+	}
+	err := binary.Write(w, binary.LittleEndian, &s)
+	if err != nil {
+		return err
+	}
+
+	// Write the FIFOs.
+	for _, fifo := range [][]Pixel{f.fifo, f.spriteFIFO} {
+		for _, px := range fifo {
+			err = binary.Write(w, binary.LittleEndian, pixelState{px.data, px.bg, px.palette, px.priority, px.index})
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Read the fetcher state.
+func (f *Fetcher) LoadState(r io.Reader) error {
+	var s fetcherState
+	err := binary.Read(r, binary.LittleEndian, &s)
+	if err != nil {
+		return err
+	}
+	if s.FIFOLen > MaxFIFOLen {
+		return fmt.Errorf("Improper FIFO size: %v", s.FIFOLen)
+	}
+	if s.SpriteFIFOLen > MaxSpriteFIFOLen {
+		return fmt.Errorf("Improper sprite FIFO size: %v", s.SpriteFIFOLen)
+	}
+
+	f.state = s.State
+	f.cycle = s.Cycle
+	f.bgMap = s.BgMap
+
+	f.tileX = s.TileX
+	f.tileY = s.TileY
+	f.tileDiscard = s.TileDiscard
+	f.tileOffset = s.TileOffset
+	f.skipFetch = s.SkipFetch
+
+	f.sprite = s.Sprite.sprite()
+	f.spritePending = s.SpritePending
+	f.spriteClocks = s.SpriteClocks
+	f.spriteTileOffset = s.SpriteTileOffset
+	f.spriteTileN = s.SpriteTileN
+	f.spriteDiscard = s.SpriteDiscard
+
+	f.tileN = s.TileN
+	f.attr = s.Attr
+	f.data0 = s.Data0
+	f.data1 = s.Data1
+
+	// Read the FIFOs.
+	f.fifo, err = readPixels(r, int(s.FIFOLen))
+	if err != nil {
+		return err
+	}
+	f.spriteFIFO, err = readPixels(r, int(s.SpriteFIFOLen))
+	return err
+}
+
+// Read a number of serialized pixels.
+func readPixels(r io.Reader, n int) ([]Pixel, error) {
+	var pixels []Pixel
+	for i := 0; i < n; i++ {
+		var px pixelState
+		err := binary.Read(r, binary.LittleEndian, &px)
+		if err != nil {
+			return nil, err
+		}
+		pixels = append(pixels, Pixel{px.Data, px.BG, px.Palette, px.Priority, px.Index})
+	}
+	return pixels, nil
+}
+
+func newSpriteState(sprite Sprite) spriteState {
+	return spriteState{
+		PosY:     sprite.posY,
+		PosX:     sprite.posX,
+		TileN:    sprite.tileN,
+		Index:    sprite.index,
+		Palette:  sprite.palette,
+		Bank:     sprite.bank,
+		FlipX:    sprite.flipX,
+		FlipY:    sprite.flipY,
+		Priority: sprite.priority,
+	}
+}
+
+func (s spriteState) sprite() Sprite {
+	return Sprite{
+		posY:     s.PosY,
+		posX:     s.PosX,
+		tileN:    s.TileN,
+		index:    s.Index,
+		palette:  s.Palette,
+		bank:     s.Bank & 0x1,
+		flipX:    s.FlipX,
+		flipY:    s.FlipY,
+		priority: s.Priority,
+	}
+}
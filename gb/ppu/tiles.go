@@ -14,6 +14,15 @@ const (
 	Tileset1         = true
 )
 
+// Flags for CGB background map attributes, which are stored in VRAM bank 1 at the same address as
+// the tile number in bank 0. The palette is in the bits below FlagAttrBank.
+const (
+	FlagAttrBank     = 3
+	FlagAttrFlipX    = 5
+	FlagAttrFlipY    = 6
+	FlagAttrPriority = 7
+)
+
 // Get the address of the tile map the window is using relative to VRAM.
 func (p *PPU) winMapAddr() uint16 {
 	if p.winMap == TileMap1 {
@@ -27,15 +36,30 @@ func (p *PPU) winMapAddr() uint16 {
 // If the tile is a sprite tile, unsigned addressing mode will be forced.
 // The offset must be in the range [0, 16).
 func (p *PPU) tileData(id uint8, offset uint8, sprite bool) uint8 {
+	return p.bankTileData(0, id, offset, sprite)
+}
+
+// Get the data of a tile from the given VRAM bank.
+func (p *PPU) bankTileData(bank uint8, id uint8, offset uint8, sprite bool) uint8 {
+	base := uint16(bank) * VRAMBankSize
 	if p.tileset == Tileset1 || sprite {
 		// Unsigned addressing mode.
-		return p.vram[uint16(id)*16+uint16(offset)]
+		return p.vram[base+uint16(id)*16+uint16(offset)]
 	} else {
 		// Signed addressing mode.
-		return p.vram[uint16(0x1000+int32(int8(id))*16)+uint16(offset)]
+		return p.vram[base+uint16(0x1000+int32(int8(id))*16)+uint16(offset)]
 	}
 }
 
+// Get the color number of a pixel in a row of tile data. Each row is two bytes, the first holding
+// the low bit of each pixel and the second holding the high bit, with the leftmost pixel in the
+// most significant bit. X is in the range [0, 8).
+func TilePixel(data0 uint8, data1 uint8, x int) uint8 {
+	lo := (data0 >> (7 - x)) & 0x1
+	hi := (data1 >> (7 - x)) & 0x1
+	return lo | hi<<1
+}
+
 // Get the address of the tile map the background is using relative to VRAM.
 func (p *PPU) bgMapAddr() uint16 {
 	if p.bgMap == TileMap1 {
@@ -2,8 +2,8 @@ package ppu
 
 // Start pixel transfer.
 func (p *PPU) startPixelTransfer() {
-	// Reset fetcher.
-	p.fetcher.Reset(p.scx, p.ly+p.scy, p.bgMapAddr())
+	// Start the fetcher on the background.
+	p.fetcher.Start(p.scx, p.ly+p.scy, p.bgMapAddr())
 
 	// Reset the x position.
 	p.lx = 0
@@ -11,29 +11,35 @@ func (p *PPU) startPixelTransfer() {
 
 // Run a step of pixel transfer.
 func (p *PPU) stepPixelTransfer() {
-	// Check for a window.
-	if p.winEnable && p.lx == p.wx && p.ly >= p.wy {
-		p.fetcher.Reset(0, p.ly-p.wy, p.winMapAddr())
-	}
-
-	// Check for a sprite.
-	oamCache := p.oamCache[:0]
-	for _, sprite := range p.oamCache {
-		if sprite.posX-8 == p.lx {
-			// Try to push the sprite to the fetcher.
-			if p.fetcher.PushSprite(sprite) {
-				// Remove the sprite from the cache if it has been accepted by the fetcher.
+	// Check for the window.
+	p.checkWindowX()
+
+	// Check for a sprite. Sprites are fetched once the current position reaches their left edge,
+	// so sprites partly off the left edge of the screen are all fetched at the start of the line.
+	// If several sprites are waiting, the one furthest left is fetched first, with ties going to
+	// the one earliest in OAM.
+	if p.spritesEnable {
+		next := -1
+		for i, sprite := range p.oamCache {
+			if int(sprite.posX)-8 > int(p.lx) {
 				continue
 			}
+			if next == -1 || sprite.posX < p.oamCache[next].posX {
+				next = i
+			}
+		}
+
+		// Try to push the sprite to the fetcher, and remove it from the cache if it is accepted.
+		if next != -1 && p.fetcher.PushSprite(p.oamCache[next]) {
+			p.oamCache = append(p.oamCache[:next], p.oamCache[next+1:]...)
 		}
-		oamCache = append(oamCache, sprite)
 	}
-	p.oamCache = oamCache
 
-	// Try to pop a pixel off the fetcher.
-	if color, ok := p.fetcher.Pop(); ok {
+	// Try to pop a pixel off the fetcher. Pixels are shifted out before the fetcher steps, so a
+	// pixel pushed into the FIFO is not shifted out until the next clock.
+	if px, ok := p.fetcher.Pop(); ok {
 		// Put the color in the frame.
-		p.frame[int(p.ly)*FrameWidth+int(p.lx)] = color
+		p.frame[int(p.ly)*FrameWidth+int(p.lx)] = p.pixelColor(px)
 
 		// Move to the next pixel.
 		p.lx++
@@ -42,27 +48,44 @@ func (p *PPU) stepPixelTransfer() {
 	// If all pixels in the scanline have been filled, move to HBlank.
 	if p.lx == FrameWidth {
 		p.mode = ModeHBlank
+		p.endWindowLine()
+		p.signalHBlank()
 		return
 	}
 
-	// Step the fetcher every 2 cycles.
-	if p.sc%2 == 0 {
-		p.fetcher.Step()
+	// Step the fetcher.
+	p.fetcher.Step()
+}
+
+// Get the color of a pixel on screen. In DMG mode, the background and window are blank while they
+// are disabled.
+func (p *PPU) pixelColor(px Pixel) uint16 {
+	if px.bg && !p.cgb && !p.bgEnable {
+		return 0
 	}
+	return p.resolve(px)
 }
 
-// Resolve the color of a pixel.
-func (p *PPU) resolve(px Pixel) uint8 {
+// Resolve the color of a pixel. In DMG mode this is a shade in the range [0, 4), and in CGB mode
+// it is a 15-bit RGB color.
+func (p *PPU) resolve(px Pixel) uint16 {
+	if p.cgb {
+		if px.bg {
+			return paletteColor(&p.bgPalettes, px.palette, px.data)
+		}
+		return paletteColor(&p.objPalettes, px.palette, px.data)
+	}
+
 	var palette uint8
 	switch {
 	case px.bg:
 		palette = p.bgp
-	case px.palette == false:
+	case px.palette == 0:
 		palette = p.obp0
-	case px.palette == true:
+	default:
 		palette = p.obp1
 	}
-	return (palette >> (px.data * 2)) & 0x3
+	return uint16((palette >> (px.data * 2)) & 0x3)
 }
 
 func (p *PPU) SCY() uint8 {
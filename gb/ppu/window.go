@@ -0,0 +1,57 @@
+package ppu
+
+// Window constants.
+const (
+	WindowXOffset = 7 // WX holds the window's X position plus 7.
+)
+
+// Check whether the window can start on the current line. Once LY has matched WY during a frame,
+// the window can start on every following line of the frame.
+func (p *PPU) checkWindowY() {
+	if p.ly == p.wy {
+		p.winYMatch = true
+	}
+}
+
+// Check whether the window starts at the current position, and switch the fetcher over to it if
+// it does.
+func (p *PPU) checkWindowX() {
+	if p.winActive || !p.winEnable || !p.winYMatch {
+		return
+	}
+	if int(p.lx)+WindowXOffset < int(p.wx) {
+		return
+	}
+
+	// Wait for the fetcher to finish fetching a sprite.
+	if p.fetcher.fetchingSprite() {
+		return
+	}
+
+	// If WX is less than 7, the window starts at the left edge of the screen, with its pixels left
+	// of the edge discarded.
+	x := uint8(0)
+	if p.wx < WindowXOffset {
+		x = WindowXOffset - p.wx
+	}
+
+	// The window is drawn from its own line counter instead of LY.
+	p.fetcher.Reset(x, p.wly, p.winMapAddr())
+	p.winActive = true
+}
+
+// Finish the window at the end of a line. The window line counter only advances on lines the window
+// was drawn on.
+func (p *PPU) endWindowLine() {
+	if p.winActive {
+		p.wly++
+		p.winActive = false
+	}
+}
+
+// Reset the window for a new frame.
+func (p *PPU) resetWindow() {
+	p.wly = 0
+	p.winYMatch = false
+	p.winActive = false
+}
@@ -0,0 +1,109 @@
+package printer
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+
+	"github.com/ruiqimao/go-gb-emu/gb/ppu"
+)
+
+// Image constants.
+const (
+	ImageWidth   = 160 // A printed row is 20 tiles wide.
+	TilesPerRow  = ImageWidth / 8
+	TileSize     = 16 // Bytes per tile.
+	MarginHeight = 8  // Each unit of margin is a blank tile row.
+
+	// Palette used when a game sends a palette of 0.
+	DefaultPalette = 0xe4
+)
+
+// Paper shades, from white to black.
+var shades = [4]color.Gray{{0xff}, {0xaa}, {0x55}, {0x00}}
+
+// Options given by a print command.
+type PrintOptions struct {
+	Sheets      uint8
+	MarginAbove uint8
+	MarginBelow uint8
+	Palette     uint8
+	Exposure    uint8 // Not emulated.
+}
+
+// A Handler is called with each printed image. If it returns an error, the printer reports it to
+// the Game Boy.
+type Handler func(img *image.Gray) error
+
+// Decode printer image data into an image. Data is a sequence of tile rows, 20 tiles each. Returns
+// nil if there is nothing to print.
+func decode(data []uint8, options PrintOptions) *image.Gray {
+	// Printing 0 sheets only feeds the paper.
+	rows := len(data) / (TilesPerRow * TileSize)
+	if options.Sheets == 0 {
+		rows = 0
+	}
+	if rows == 0 && options.MarginAbove == 0 && options.MarginBelow == 0 {
+		return nil
+	}
+
+	palette := options.Palette
+	if palette == 0x00 {
+		palette = DefaultPalette
+	}
+
+	above := int(options.MarginAbove) * MarginHeight
+	below := int(options.MarginBelow) * MarginHeight
+	img := image.NewGray(image.Rect(0, 0, ImageWidth, above+rows*8+below))
+
+	// Margins are blank paper.
+	for i := range img.Pix {
+		img.Pix[i] = shades[0].Y
+	}
+
+	for row := 0; row < rows; row++ {
+		for tile := 0; tile < TilesPerRow; tile++ {
+			offset := (row*TilesPerRow + tile) * TileSize
+			for y := 0; y < 8; y++ {
+				data0 := data[offset+y*2]
+				data1 := data[offset+y*2+1]
+				for x := 0; x < 8; x++ {
+					n := ppu.TilePixel(data0, data1, x)
+					shade := (palette >> (n * 2)) & 0x3
+					img.SetGray(tile*8+x, above+row*8+y, shades[shade])
+				}
+			}
+		}
+	}
+	return img
+}
+
+// Create a handler that writes each printed image to a numbered PNG file in a directory. Existing
+// files are skipped over.
+func SavePNG(dir string) Handler {
+	n := 0
+	return func(img *image.Gray) error {
+		var f *os.File
+		var err error
+		for {
+			n++
+			path := filepath.Join(dir, fmt.Sprintf("print-%04d.png", n))
+			f, err = os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+			if !os.IsExist(err) {
+				break
+			}
+		}
+		if err != nil {
+			return err
+		}
+		err = png.Encode(f, img)
+		if err != nil {
+			f.Close()
+			return err
+		}
+		return f.Close()
+	}
+}
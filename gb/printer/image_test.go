@@ -0,0 +1,107 @@
+package printer
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDecode(t *testing.T) {
+	tests := []struct {
+		name    string
+		options PrintOptions
+		above   int
+		below   int
+		shades  [4]uint8 // Shade of each color number.
+	}{
+		{"Palette", PrintOptions{Sheets: 1, Palette: 0xe4}, 0, 0, [4]uint8{0xff, 0xaa, 0x55, 0x00}},
+		{"Reversed", PrintOptions{Sheets: 1, Palette: 0x1b}, 0, 0, [4]uint8{0x00, 0x55, 0xaa, 0xff}},
+		{"Default", PrintOptions{Sheets: 1}, 0, 0, [4]uint8{0xff, 0xaa, 0x55, 0x00}},
+		{"Margins", PrintOptions{Sheets: 1, MarginAbove: 1, MarginBelow: 3, Palette: 0xe4}, 8, 24,
+			[4]uint8{0xff, 0xaa, 0x55, 0x00}},
+	}
+	data := append(tileRow(), tileRow()...)
+	for _, test := range tests {
+		img := decode(data, test.options)
+		height := test.above + 16 + test.below
+		if img.Bounds() != image.Rect(0, 0, ImageWidth, height) {
+			t.Errorf("%v: got bounds %v, expected height %v", test.name, img.Bounds(), height)
+			continue
+		}
+		for y := 0; y < height; y++ {
+			for x := 0; x < ImageWidth; x++ {
+				shade := uint8(0xff)
+				if y >= test.above && y < test.above+16 {
+					shade = test.shades[(x/8)%4]
+				}
+				if v := img.GrayAt(x, y).Y; v != shade {
+					t.Fatalf("%v: got shade %02x at (%v, %v), expected %02x", test.name, v, x, y,
+						shade)
+				}
+			}
+		}
+	}
+}
+
+func TestDecodeEmpty(t *testing.T) {
+	// Printing 0 sheets only feeds the paper.
+	if img := decode(tileRow(), PrintOptions{Sheets: 0}); img != nil {
+		t.Errorf("Got an image of %v from 0 sheets", img.Bounds())
+	}
+	img := decode(tileRow(), PrintOptions{Sheets: 0, MarginBelow: 2})
+	if img == nil || img.Bounds() != image.Rect(0, 0, ImageWidth, 16) {
+		t.Errorf("Got %v from a paper feed", img)
+	}
+
+	// A partial row of tiles is not printed.
+	if img := decode(tileRow()[:TileSize], PrintOptions{Sheets: 1}); img != nil {
+		t.Errorf("Got an image of %v from a partial row", img.Bounds())
+	}
+}
+
+func TestSavePNG(t *testing.T) {
+	dir, err := ioutil.TempDir("", "printer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// Existing prints are not overwritten.
+	existing := filepath.Join(dir, "print-0001.png")
+	err = ioutil.WriteFile(existing, []uint8("existing"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	handler := SavePNG(dir)
+	img := decode(tileRow(), PrintOptions{Sheets: 1, Palette: 0xe4})
+	for i := 0; i < 2; i++ {
+		err = handler(img)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if data, _ := ioutil.ReadFile(existing); string(data) != "existing" {
+		t.Error("Overwrote an existing print")
+	}
+	for _, name := range []string{"print-0002.png", "print-0003.png"} {
+		f, err := os.Open(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		saved, err := png.Decode(f)
+		f.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		gray, ok := saved.(*image.Gray)
+		if !ok || gray.Bounds() != img.Bounds() || !bytes.Equal(gray.Pix, img.Pix) {
+			t.Errorf("%v does not match the printed image", name)
+		}
+	}
+}
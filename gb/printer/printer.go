@@ -0,0 +1,264 @@
+package printer
+
+import (
+	"github.com/ruiqimao/go-gb-emu/gb/serial"
+)
+
+// Packet commands.
+const (
+	CommandInit   = 0x01
+	CommandPrint  = 0x02
+	CommandData   = 0x04
+	CommandStatus = 0x0f
+)
+
+// Status flags.
+const (
+	FlagChecksumError = 0
+	FlagPrinting      = 1
+	FlagImageFull     = 2
+	FlagUnprocessed   = 3
+	FlagPacketError   = 4
+	FlagPaperJam      = 5
+	FlagOtherError    = 6
+	FlagBatteryLow    = 7
+)
+
+// Protocol constants.
+const (
+	Magic0    = 0x88
+	Magic1    = 0x33
+	AliveByte = 0x81 // Sent in response to the first byte after the checksum.
+
+	MaxPacketData = 0x280  // A data packet holds 2 rows of 20 tiles.
+	MaxBufferSize = 0x2000 // Size of the printer RAM.
+
+	// How long printing takes, in polls of the serial port. The port is polled at 8192Hz, so this
+	// is 1 second.
+	PrintPolls = 8192
+)
+
+// Packet receiving states.
+const (
+	stateMagic0 = iota
+	stateMagic1
+	stateCommand
+	stateCompression
+	stateLengthLow
+	stateLengthHigh
+	stateData
+	stateChecksumLow
+	stateChecksumHigh
+	stateAlive
+	stateStatus
+)
+
+// Game Boy Printer, connected as a link cable peer. The Game Boy always provides the clock, and
+// the printer answers each byte it receives.
+// The protocol is documented at https://gbdev.io/pandocs/Gameboy_Printer.html.
+type Printer struct {
+	// Called with each printed image.
+	handler Handler
+
+	// Packet being received.
+	state      int
+	command    uint8
+	compressed bool
+	length     uint16
+	data       []uint8
+	sum        uint16
+	checksum   uint16
+
+	// Printer status and image data waiting to be printed.
+	status uint8
+	buffer []uint8
+
+	// Polls left until printing finishes.
+	printPolls int
+}
+
+func NewPrinter(handler Handler) *Printer {
+	return &Printer{
+		handler: handler,
+	}
+}
+
+func (p *Printer) Exchange(port serial.LinkPort, out uint8) uint8 {
+	return p.receive(out)
+}
+
+func (p *Printer) Poll(port serial.LinkPort) {
+	if p.printPolls > 0 {
+		p.printPolls--
+		if p.printPolls == 0 {
+			p.status &^= 0x1 << FlagPrinting
+		}
+	}
+}
+
+// Receive a byte of a packet. Returns the byte sent back.
+func (p *Printer) receive(v uint8) uint8 {
+	// Everything between the magic bytes and the checksum counts towards the checksum.
+	if p.state >= stateCommand && p.state <= stateData {
+		p.sum += uint16(v)
+	}
+
+	switch p.state {
+
+	case stateMagic0:
+		if v == Magic0 {
+			p.state = stateMagic1
+		}
+
+	case stateMagic1:
+		p.state = stateMagic0
+		if v == Magic1 {
+			p.state = stateCommand
+			p.sum = 0
+			p.data = p.data[:0]
+		}
+
+	case stateCommand:
+		p.command = v
+		p.state = stateCompression
+
+	case stateCompression:
+		p.compressed = v&0x1 != 0
+		p.state = stateLengthLow
+
+	case stateLengthLow:
+		p.length = uint16(v)
+		p.state = stateLengthHigh
+
+	case stateLengthHigh:
+		p.length |= uint16(v) << 8
+		p.state = stateData
+		if p.length == 0 {
+			p.state = stateChecksumLow
+		}
+
+	case stateData:
+		p.data = append(p.data, v)
+		if len(p.data) == int(p.length) {
+			p.state = stateChecksumLow
+		}
+
+	case stateChecksumLow:
+		p.checksum = uint16(v)
+		p.state = stateChecksumHigh
+
+	case stateChecksumHigh:
+		p.checksum |= uint16(v) << 8
+		p.state = stateAlive
+		p.execute()
+
+	case stateAlive:
+		p.state = stateStatus
+		return AliveByte
+
+	case stateStatus:
+		p.state = stateMagic0
+		return p.status
+
+	}
+	return 0x00
+}
+
+// Execute the packet that was just received.
+func (p *Printer) execute() {
+	if p.checksum != p.sum {
+		p.status |= 0x1 << FlagChecksumError
+		return
+	}
+	p.status &^= 0x1<<FlagChecksumError | 0x1<<FlagPacketError
+
+	switch p.command {
+
+	case CommandInit:
+		p.buffer = p.buffer[:0]
+		p.status = 0x00
+		p.printPolls = 0
+
+	case CommandData:
+		data := p.data
+		if p.compressed {
+			data = decompress(data)
+		}
+		if len(p.buffer)+len(data) > MaxBufferSize {
+			data = data[:MaxBufferSize-len(p.buffer)]
+		}
+		p.buffer = append(p.buffer, data...)
+		if len(p.buffer) > 0 {
+			p.status |= 0x1 << FlagUnprocessed
+		}
+		if len(p.buffer) == MaxBufferSize {
+			p.status |= 0x1 << FlagImageFull
+		}
+
+	case CommandPrint:
+		if len(p.data) < 4 {
+			p.status |= 0x1 << FlagPacketError
+			return
+		}
+		p.print(PrintOptions{
+			Sheets:      p.data[0],
+			MarginAbove: p.data[1] >> 4,
+			MarginBelow: p.data[1] & 0xf,
+			Palette:     p.data[2],
+			Exposure:    p.data[3],
+		})
+
+	case CommandStatus:
+		// Only the status is returned.
+
+	default:
+		p.status |= 0x1 << FlagPacketError
+
+	}
+}
+
+// Print the buffered image data.
+func (p *Printer) print(options PrintOptions) {
+	img := decode(p.buffer, options)
+	p.buffer = p.buffer[:0]
+	p.status &^= 0x1<<FlagUnprocessed | 0x1<<FlagImageFull
+
+	if p.handler != nil && img != nil {
+		err := p.handler(img)
+		if err != nil {
+			p.status |= 0x1 << FlagOtherError
+			return
+		}
+	}
+
+	p.status |= 0x1 << FlagPrinting
+	p.printPolls = PrintPolls
+}
+
+// Decompress RLE compressed data. A control byte with the high bit set is followed by a byte
+// that is repeated (control & 0x7f) + 2 times. Otherwise, it is followed by control + 1 literal
+// bytes.
+func decompress(data []uint8) []uint8 {
+	var out []uint8
+	for i := 0; i < len(data); {
+		control := data[i]
+		i++
+		if control&0x80 != 0 {
+			if i >= len(data) {
+				break
+			}
+			for n := 0; n < int(control&0x7f)+2; n++ {
+				out = append(out, data[i])
+			}
+			i++
+		} else {
+			n := int(control) + 1
+			if i+n > len(data) {
+				n = len(data) - i
+			}
+			out = append(out, data[i:i+n]...)
+			i += n
+		}
+	}
+	return out
+}
@@ -0,0 +1,250 @@
+package printer
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"testing"
+)
+
+// Make a packet with a correct checksum.
+func makePacket(command uint8, compressed bool, data []uint8) []uint8 {
+	compression := uint8(0x00)
+	if compressed {
+		compression = 0x01
+	}
+	packet := []uint8{Magic0, Magic1, command, compression, uint8(len(data)), uint8(len(data) >> 8)}
+	packet = append(packet, data...)
+	var sum uint16
+	for _, v := range packet[2:] {
+		sum += uint16(v)
+	}
+	return append(packet, uint8(sum), uint8(sum>>8))
+}
+
+// Send bytes to the printer, followed by the 2 bytes that clock out the alive byte and the status.
+// Returns every byte sent back.
+func send(p *Printer, packet []uint8) []uint8 {
+	var replies []uint8
+	for _, v := range append(packet, 0x00, 0x00) {
+		replies = append(replies, p.Exchange(nil, v))
+	}
+	return replies
+}
+
+// Send a packet and return the status.
+func sendPacket(t *testing.T, p *Printer, command uint8, compressed bool, data []uint8) uint8 {
+	t.Helper()
+	replies := send(p, makePacket(command, compressed, data))
+	if alive := replies[len(replies)-2]; alive != AliveByte {
+		t.Fatalf("Command %02x: got alive byte %02x", command, alive)
+	}
+	return replies[len(replies)-1]
+}
+
+// Make a row of 20 tiles, where each tile is filled with color number tile%4.
+func tileRow() []uint8 {
+	var data []uint8
+	for tile := 0; tile < TilesPerRow; tile++ {
+		lo := uint8(0x00)
+		hi := uint8(0x00)
+		if tile&0x1 != 0 {
+			lo = 0xff
+		}
+		if tile&0x2 != 0 {
+			hi = 0xff
+		}
+		for y := 0; y < 8; y++ {
+			data = append(data, lo, hi)
+		}
+	}
+	return data
+}
+
+func TestPacket(t *testing.T) {
+	p := NewPrinter(nil)
+
+	// Bytes before the magic bytes are ignored, as is a first magic byte without the second.
+	packet := append([]uint8{0x00, 0x12, Magic0, 0x00}, makePacket(CommandStatus, false, nil)...)
+	replies := send(p, packet)
+	expected := make([]uint8, len(replies))
+	expected[len(expected)-2] = AliveByte
+	if !bytes.Equal(replies, expected) {
+		t.Errorf("Got replies % x, expected % x", replies, expected)
+	}
+
+	// The printer is ready for the next packet straight away.
+	if status := sendPacket(t, p, CommandStatus, false, nil); status != 0x00 {
+		t.Errorf("Got status %02x", status)
+	}
+}
+
+func TestChecksum(t *testing.T) {
+	p := NewPrinter(nil)
+
+	// A DATA packet with a bad checksum is not used.
+	packet := makePacket(CommandData, false, tileRow())
+	packet[len(packet)-1]++
+	replies := send(p, packet)
+	if status := replies[len(replies)-1]; status != 0x1<<FlagChecksumError {
+		t.Errorf("Got status %02x after a bad checksum", status)
+	}
+	if len(p.buffer) != 0 {
+		t.Errorf("Buffered %v bytes from a bad packet", len(p.buffer))
+	}
+
+	// The error is cleared by the next good packet.
+	if status := sendPacket(t, p, CommandStatus, false, nil); status != 0x00 {
+		t.Errorf("Got status %02x after a good packet", status)
+	}
+}
+
+func TestStatus(t *testing.T) {
+	var printed []*image.Gray
+	p := NewPrinter(func(img *image.Gray) error {
+		printed = append(printed, img)
+		return nil
+	})
+
+	if status := sendPacket(t, p, CommandInit, false, nil); status != 0x00 {
+		t.Errorf("INIT: got status %02x", status)
+	}
+
+	// Data waits to be printed.
+	if status := sendPacket(t, p, CommandData, false, tileRow()); status != 0x1<<FlagUnprocessed {
+		t.Errorf("DATA: got status %02x", status)
+	}
+	if status := sendPacket(t, p, CommandData, false, nil); status != 0x1<<FlagUnprocessed {
+		t.Errorf("Empty DATA: got status %02x", status)
+	}
+	if status := sendPacket(t, p, CommandStatus, false, nil); status != 0x1<<FlagUnprocessed {
+		t.Errorf("INQUIRY: got status %02x", status)
+	}
+
+	// Printing takes a while.
+	status := sendPacket(t, p, CommandPrint, false, []uint8{0x01, 0x00, 0xe4, 0x40})
+	if status != 0x1<<FlagPrinting {
+		t.Errorf("PRINT: got status %02x", status)
+	}
+	if len(printed) != 1 {
+		t.Fatalf("Printed %v images", len(printed))
+	}
+	for i := 0; i < PrintPolls-1; i++ {
+		p.Poll(nil)
+	}
+	if status := sendPacket(t, p, CommandStatus, false, nil); status != 0x1<<FlagPrinting {
+		t.Errorf("INQUIRY while printing: got status %02x", status)
+	}
+	p.Poll(nil)
+	if status := sendPacket(t, p, CommandStatus, false, nil); status != 0x00 {
+		t.Errorf("INQUIRY after printing: got status %02x", status)
+	}
+
+	// Data past the end of the buffer is dropped.
+	for i := 0; i <= MaxBufferSize/len(tileRow()); i++ {
+		status = sendPacket(t, p, CommandData, false, tileRow())
+	}
+	if status != 0x1<<FlagUnprocessed|0x1<<FlagImageFull {
+		t.Errorf("Full DATA: got status %02x", status)
+	}
+	if len(p.buffer) != MaxBufferSize {
+		t.Errorf("Buffered %v bytes", len(p.buffer))
+	}
+
+	// Unknown commands and short PRINT packets are packet errors.
+	if status := sendPacket(t, p, 0x03, false, nil); status&(0x1<<FlagPacketError) == 0 {
+		t.Errorf("Unknown command: got status %02x", status)
+	}
+	status = sendPacket(t, p, CommandPrint, false, []uint8{0x01})
+	if status&(0x1<<FlagPacketError) == 0 {
+		t.Errorf("Short PRINT: got status %02x", status)
+	}
+
+	// INIT clears everything.
+	if status := sendPacket(t, p, CommandInit, false, nil); status != 0x00 {
+		t.Errorf("INIT: got status %02x", status)
+	}
+}
+
+func TestHandlerError(t *testing.T) {
+	p := NewPrinter(func(img *image.Gray) error {
+		return errors.New("Out of paper")
+	})
+	sendPacket(t, p, CommandData, false, tileRow())
+	status := sendPacket(t, p, CommandPrint, false, []uint8{0x01, 0x00, 0xe4, 0x40})
+	if status != 0x1<<FlagOtherError {
+		t.Errorf("Got status %02x", status)
+	}
+}
+
+func TestDecompress(t *testing.T) {
+	tests := []struct {
+		in  []uint8
+		out []uint8
+	}{
+		{nil, nil},
+
+		// Literal runs of control + 1 bytes.
+		{[]uint8{0x00, 0x12}, []uint8{0x12}},
+		{[]uint8{0x02, 0x01, 0x02, 0x03}, []uint8{0x01, 0x02, 0x03}},
+
+		// Repeated runs of (control & 0x7f) + 2 bytes.
+		{[]uint8{0x80, 0x34}, []uint8{0x34, 0x34}},
+		{[]uint8{0x83, 0x56}, []uint8{0x56, 0x56, 0x56, 0x56, 0x56}},
+
+		// Mixed runs.
+		{
+			[]uint8{0x01, 0x01, 0x02, 0x81, 0xff, 0x00, 0x03},
+			[]uint8{0x01, 0x02, 0xff, 0xff, 0xff, 0x03},
+		},
+
+		// Truncated runs are cut short.
+		{[]uint8{0x03, 0x01, 0x02}, []uint8{0x01, 0x02}},
+		{[]uint8{0x00, 0x01, 0x85}, []uint8{0x01}},
+	}
+	for _, test := range tests {
+		if out := decompress(test.in); !bytes.Equal(out, test.out) {
+			t.Errorf("Decompress % x: got % x, expected % x", test.in, out, test.out)
+		}
+	}
+}
+
+// Compress data, repeating runs of equal bytes and sending the rest one byte at a time.
+func compress(data []uint8) []uint8 {
+	var out []uint8
+	for i := 0; i < len(data); {
+		n := 1
+		for i+n < len(data) && data[i+n] == data[i] && n < 0x7f+2 {
+			n++
+		}
+		if n >= 2 {
+			out = append(out, 0x80|uint8(n-2), data[i])
+		} else {
+			out = append(out, 0x00, data[i])
+		}
+		i += n
+	}
+	return out
+}
+
+func TestCompressedData(t *testing.T) {
+	var printed []*image.Gray
+	p := NewPrinter(func(img *image.Gray) error {
+		printed = append(printed, img)
+		return nil
+	})
+
+	row := tileRow()
+	compressed := compress(row)
+
+	for i, data := range [][]uint8{row, compressed} {
+		sendPacket(t, p, CommandData, i == 1, data)
+		if !bytes.Equal(p.buffer, row) {
+			t.Fatalf("Wrong buffered data: % x", p.buffer)
+		}
+		sendPacket(t, p, CommandPrint, false, []uint8{0x01, 0x00, 0xe4, 0x40})
+	}
+	if len(printed) != 2 || !bytes.Equal(printed[0].Pix, printed[1].Pix) {
+		t.Error("Compressed data printed differently")
+	}
+}
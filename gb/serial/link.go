@@ -0,0 +1,290 @@
+package serial
+
+import (
+	"io"
+	"net"
+	"sync"
+)
+
+// Link timing constants.
+const (
+	// The two sides of a link synchronize every this many polls, so neither side can get more than
+	// this many bit periods ahead of the other.
+	SyncPolls = 8
+)
+
+// Link messages.
+const (
+	msgSync     = 0 // The sender reached its next synchronization point.
+	msgTransfer = 1 // The sender clocked a byte out.
+	msgReply    = 2 // The byte shifted out in response to a transfer.
+)
+
+// Message sent over a link.
+type message struct {
+	kind uint8
+	data uint8
+}
+
+// Link cable between two emulated Game Boys, either in the same process or over a socket.
+//
+// The two sides run in lockstep: every SyncPolls polls, each side waits for the other to reach
+// the same point. The side that starts a transfer with the internal clock is the master, and
+// waits for the other side to shift its byte back. While waiting, each side keeps answering the
+// other, so two masters or a master and a waiting side can never deadlock.
+//
+// Because the sides wait for each other, linked Game Boys must be run on separate goroutines.
+// If the other side disconnects, the link behaves as if the cable was unplugged.
+type Link struct {
+	in   <-chan message
+	send func(message) error
+
+	// Closes the underlying connection.
+	close func() error
+
+	// Number of polls since the last synchronization point.
+	polls int
+
+	// Number of synchronization points the other side has reached that this side has not.
+	syncs int
+
+	// Whether the other side has disconnected. This can be checked from other goroutines, so it
+	// is guarded by the mutex.
+	disconnected bool
+	mutex        sync.Mutex
+}
+
+// Create a link between two Game Boys in the same process.
+func NewCable() (*Link, *Link) {
+	a := make(chan message, 64)
+	b := make(chan message, 64)
+
+	var once sync.Once
+	done := make(chan bool)
+	closeCable := func() error {
+		once.Do(func() {
+			close(done)
+		})
+		return nil
+	}
+
+	// Each side reads from its own channel, and writes to the other side's.
+	sender := func(ch chan message) func(message) error {
+		return func(msg message) error {
+			select {
+			case ch <- msg:
+				return nil
+			case <-done:
+				return io.ErrClosedPipe
+			}
+		}
+	}
+	receiver := func(ch chan message) <-chan message {
+		out := make(chan message)
+		go func() {
+			defer close(out)
+			for {
+				select {
+				case msg := <-ch:
+					select {
+					case out <- msg:
+					case <-done:
+						return
+					}
+				case <-done:
+					return
+				}
+			}
+		}()
+		return out
+	}
+
+	la := &Link{
+		in:    receiver(a),
+		send:  sender(b),
+		close: closeCable,
+	}
+	lb := &Link{
+		in:    receiver(b),
+		send:  sender(a),
+		close: closeCable,
+	}
+	return la, lb
+}
+
+// Create a link over a connection to another emulator instance.
+func NewLink(conn net.Conn) *Link {
+	in := make(chan message, 64)
+
+	var once sync.Once
+	done := make(chan bool)
+	closeLink := func() error {
+		err := conn.Close()
+		once.Do(func() {
+			close(done)
+		})
+		return err
+	}
+
+	// Read messages until the connection is closed. Stop waiting to hand a message over once the
+	// link is closed, since nothing reads them after that.
+	go func() {
+		defer close(in)
+		buf := make([]uint8, 2)
+		for {
+			_, err := io.ReadFull(conn, buf)
+			if err != nil {
+				return
+			}
+			select {
+			case in <- message{buf[0], buf[1]}:
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	return &Link{
+		in: in,
+		send: func(msg message) error {
+			_, err := conn.Write([]uint8{msg.kind, msg.data})
+			return err
+		},
+		close: closeLink,
+	}
+}
+
+// Wait for another emulator instance to connect. The network is "tcp" or "unix".
+func ListenLink(network string, address string) (*Link, error) {
+	listener, err := net.Listen(network, address)
+	if err != nil {
+		return nil, err
+	}
+	defer listener.Close()
+
+	conn, err := listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+	return NewLink(conn), nil
+}
+
+// Connect to another emulator instance that is waiting with ListenLink.
+func DialLink(network string, address string) (*Link, error) {
+	conn, err := net.Dial(network, address)
+	if err != nil {
+		return nil, err
+	}
+	return NewLink(conn), nil
+}
+
+// Disconnect the link. This can be called from any goroutine, and stops the emulation loop from
+// waiting on the other side.
+func (l *Link) Close() error {
+	return l.close()
+}
+
+// Get whether the other side has disconnected.
+func (l *Link) Disconnected() bool {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	return l.disconnected
+}
+
+// Mark the other side as disconnected.
+func (l *Link) disconnect() {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	l.disconnected = true
+}
+
+func (l *Link) Exchange(port LinkPort, out uint8) uint8 {
+	if !l.write(message{msgTransfer, out}) {
+		return 0xff
+	}
+
+	// Wait for the other side to shift its byte back.
+	for {
+		msg, ok := l.read(port)
+		if !ok {
+			return 0xff
+		}
+		if msg.kind == msgReply {
+			return msg.data
+		}
+	}
+}
+
+func (l *Link) Poll(port LinkPort) {
+	if l.Disconnected() {
+		return
+	}
+
+	// Answer any transfers that have arrived.
+	for done := false; !done; {
+		select {
+		case msg, ok := <-l.in:
+			if !ok {
+				l.disconnect()
+				return
+			}
+			l.handle(port, msg)
+		default:
+			done = true
+		}
+	}
+
+	// Wait for the other side at synchronization points.
+	l.polls++
+	if l.polls < SyncPolls {
+		return
+	}
+	l.polls = 0
+	if !l.write(message{msgSync, 0x00}) {
+		return
+	}
+	for l.syncs == 0 {
+		_, ok := l.read(port)
+		if !ok {
+			return
+		}
+	}
+	l.syncs--
+}
+
+// Send a message. Returns false if the other side has disconnected.
+func (l *Link) write(msg message) bool {
+	if l.Disconnected() {
+		return false
+	}
+	if l.send(msg) != nil {
+		l.disconnect()
+		return false
+	}
+	return true
+}
+
+// Wait for the next message, handling it if it does not need a response from the caller.
+// Returns false if the other side has disconnected.
+func (l *Link) read(port LinkPort) (message, bool) {
+	if l.Disconnected() {
+		return message{}, false
+	}
+	msg, ok := <-l.in
+	if !ok {
+		l.disconnect()
+		return message{}, false
+	}
+	l.handle(port, msg)
+	return msg, true
+}
+
+// Handle a message from the other side.
+func (l *Link) handle(port LinkPort, msg message) {
+	switch msg.kind {
+	case msgSync:
+		l.syncs++
+	case msgTransfer:
+		l.write(message{msgReply, port.Receive(msg.data)})
+	}
+}
@@ -0,0 +1,255 @@
+package serial
+
+import (
+	"net"
+	"testing"
+	"time"
+)
+
+// A port that shifts out 0xff.
+type testPort struct{}
+
+func (p testPort) Receive(in uint8) uint8 {
+	return 0xff
+}
+
+// Check that closing a link stops it from waiting for the other side.
+func testCloseWhileWaiting(t *testing.T, l *Link) {
+	done := make(chan bool)
+	go func() {
+		// The other side never polls, so this waits at the first synchronization point.
+		for i := 0; i < SyncPolls; i++ {
+			l.Poll(testPort{})
+		}
+		close(done)
+	}()
+
+	// Check for a disconnection while the emulation loop is waiting.
+	time.Sleep(10 * time.Millisecond)
+	if l.Disconnected() {
+		t.Fatal("Disconnected before closing")
+	}
+
+	err := l.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Still waiting after closing")
+	}
+	if !l.Disconnected() {
+		t.Error("Not disconnected after closing")
+	}
+	if v := l.Exchange(testPort{}, 0x12); v != 0xff {
+		t.Errorf("Exchanged %02x after closing", v)
+	}
+}
+
+func TestCableClose(t *testing.T) {
+	a, _ := NewCable()
+	testCloseWhileWaiting(t, a)
+}
+
+func TestLinkClose(t *testing.T) {
+	conn, other := net.Pipe()
+	defer other.Close()
+
+	// Keep the other side from blocking writes.
+	go func() {
+		buf := make([]uint8, 2)
+		for {
+			if _, err := other.Read(buf); err != nil {
+				return
+			}
+		}
+	}()
+	testCloseWhileWaiting(t, NewLink(conn))
+}
+
+func TestLinkCloseWithUnreadMessages(t *testing.T) {
+	conn, other := net.Pipe()
+	defer other.Close()
+	l := NewLink(conn)
+
+	// Fill the message buffer so that the reader is left waiting to hand over a message.
+	go func() {
+		for {
+			if _, err := other.Write([]uint8{msgTransfer, 0x00}); err != nil {
+				return
+			}
+		}
+	}()
+	for len(l.in) < cap(l.in) {
+		time.Sleep(time.Millisecond)
+	}
+
+	// Once the link is closed, the reader stops without handing over the message it was holding.
+	err := l.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(10 * time.Millisecond)
+	n := 0
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case _, ok := <-l.in:
+			if !ok {
+				if n != cap(l.in) {
+					t.Errorf("Reader handed over %v messages after closing", n-cap(l.in))
+				}
+				return
+			}
+			n++
+		case <-timeout:
+			t.Fatal("Reader still running after closing")
+		}
+	}
+}
+
+// An MMU that counts serial interrupts.
+type testMMU struct {
+	interrupts int
+}
+
+func (m *testMMU) RequestInterrupt(interrupt int) {
+	if interrupt == InterruptSerial {
+		m.interrupts++
+	}
+}
+
+// Make a serial port attached to a link.
+func newLinkedSerial(l *Link) (*Serial, *testMMU) {
+	m := &testMMU{}
+	s := NewSerial()
+	s.AttachMMU(m)
+	s.AttachPeer(l)
+	return s, m
+}
+
+// Run two linked serial ports on separate goroutines, as linked Game Boys are. Each port starts a
+// transfer with its own SB and SC values, then runs for the same number of clocks.
+func runLinked(t *testing.T, a, b *Serial, sb [2]uint8, sc [2]uint8, clocks int) {
+	t.Helper()
+	done := make(chan bool)
+	for i, s := range []*Serial{a, b} {
+		go func(s *Serial, sb uint8, sc uint8) {
+			s.SetSB(sb)
+			s.SetSC(sc)
+			for j := 0; j < clocks; j++ {
+				s.Step()
+			}
+			done <- true
+		}(s, sb[i], sc[i])
+	}
+	timeout := time.After(5 * time.Second)
+	for i := 0; i < 2; i++ {
+		select {
+		case <-done:
+		case <-timeout:
+			t.Fatal("Linked ports did not finish")
+		}
+	}
+}
+
+// Check transfers between two ends of a link.
+func testExchange(t *testing.T, newLinks func() (*Link, *Link)) {
+	tests := []struct {
+		name string
+		sc   [2]uint8
+		sb   [2]uint8 // SB after the transfer.
+		ints [2]int   // Serial interrupts after the transfer.
+	}{
+		// The master provides the clock, and the slave waits with the internal clock bit clear.
+		{"MasterSlave", [2]uint8{0x81, 0x80}, [2]uint8{0xa5, 0x5a}, [2]int{1, 1}},
+
+		// The other side is not waiting for a transfer, so the master shifts in 0xFF.
+		{"NotWaiting", [2]uint8{0x81, 0x00}, [2]uint8{0xff, 0xa5}, [2]int{1, 0}},
+
+		// Both sides provide the clock, so neither shifts anything to the other.
+		{"TwoMasters", [2]uint8{0x81, 0x81}, [2]uint8{0xff, 0xff}, [2]int{1, 1}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			la, lb := newLinks()
+			defer la.Close()
+			defer lb.Close()
+			a, ma := newLinkedSerial(la)
+			b, mb := newLinkedSerial(lb)
+
+			// Run long enough for the master to shift in all 8 bits.
+			runLinked(t, a, b, [2]uint8{0x5a, 0xa5}, test.sc, BitClocks*SyncPolls*4)
+
+			if a.SB() != test.sb[0] || b.SB() != test.sb[1] {
+				t.Errorf("Got SB %02x and %02x, expected %02x and %02x", a.SB(), b.SB(),
+					test.sb[0], test.sb[1])
+			}
+			if ma.interrupts != test.ints[0] || mb.interrupts != test.ints[1] {
+				t.Errorf("Got %v and %v interrupts, expected %v and %v", ma.interrupts,
+					mb.interrupts, test.ints[0], test.ints[1])
+			}
+			if a.SC()&(1<<FlagTransfer) != 0 {
+				t.Errorf("Transfer still running: SC %02x", a.SC())
+			}
+		})
+	}
+}
+
+func TestCableExchange(t *testing.T) {
+	testExchange(t, NewCable)
+}
+
+func TestLinkExchange(t *testing.T) {
+	testExchange(t, func() (*Link, *Link) {
+		a, b := net.Pipe()
+		return NewLink(a), NewLink(b)
+	})
+}
+
+func TestLinkLockstep(t *testing.T) {
+	la, lb := NewCable()
+	defer la.Close()
+	a, _ := newLinkedSerial(la)
+	b, _ := newLinkedSerial(lb)
+
+	// One side runs on its own until it reaches the first synchronization point.
+	steps := make(chan int)
+	go func() {
+		for i := 1; i <= BitClocks*SyncPolls*2; i++ {
+			a.Step()
+			steps <- i
+		}
+		close(steps)
+	}()
+	last := 0
+	for stalled := false; !stalled; {
+		select {
+		case last = <-steps:
+		case <-time.After(50 * time.Millisecond):
+			stalled = true
+		}
+	}
+	if last != BitClocks*SyncPolls-1 {
+		t.Fatalf("Ran %v clocks ahead of the other side, expected %v", last, BitClocks*SyncPolls-1)
+	}
+
+	// Once the other side catches up, both carry on.
+	go func() {
+		for i := 0; i < BitClocks*SyncPolls*2; i++ {
+			b.Step()
+		}
+	}()
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case _, ok := <-steps:
+			if !ok {
+				return
+			}
+		case <-timeout:
+			t.Fatal("Still waiting after the other side caught up")
+		}
+	}
+}
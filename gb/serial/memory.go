@@ -0,0 +1,18 @@
+package serial
+
+// Serial interrupt.
+const (
+	InterruptSerial = 3
+)
+
+// MMU interface.
+type MMU interface {
+	RequestInterrupt(int)
+}
+
+// Request a serial interrupt.
+func (s *Serial) interruptSerial() {
+	if s.mmu != nil {
+		s.mmu.RequestInterrupt(InterruptSerial)
+	}
+}
@@ -0,0 +1,45 @@
+package serial
+
+import (
+	"io"
+)
+
+// LinkPort is the serial port end of a link cable.
+type LinkPort interface {
+	// Clock a byte in from a peer that provides the clock. Returns the byte shifted out in
+	// response.
+	Receive(in uint8) uint8
+}
+
+// LinkPeer is the device on the other end of the link cable. It is only called from the
+// emulation loop, and may only use the port during its calls.
+type LinkPeer interface {
+	// Exchange a byte while this side provides the clock. The peer is given the byte shifted out,
+	// and returns the byte shifted in.
+	Exchange(port LinkPort, out uint8) uint8
+
+	// Called every BitClocks clocks. If the peer provides the clock, it clocks bytes in through
+	// the port.
+	Poll(port LinkPort)
+}
+
+// Peer that writes every byte sent to it, and never sends anything back. Test ROMs, such as
+// blargg's, print their results this way.
+type Writer struct {
+	w io.Writer
+}
+
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{
+		w: w,
+	}
+}
+
+func (w *Writer) Exchange(port LinkPort, out uint8) uint8 {
+	w.w.Write([]uint8{out})
+	return 0xff
+}
+
+func (w *Writer) Poll(port LinkPort) {
+	// The writer never sends anything.
+}
@@ -0,0 +1,148 @@
+package serial
+
+import (
+	"github.com/ruiqimao/go-gb-emu/utils"
+)
+
+// SC flags.
+const (
+	FlagTransfer      = 7
+	FlagInternalClock = 0
+)
+
+// Timing constants.
+const (
+	BitClocks = 512 // The internal clock shifts bits at 8192Hz.
+)
+
+// Serial port.
+type Serial struct {
+	mmu MMU
+
+	// Device on the other end of the link cable.
+	peer LinkPeer
+
+	// Registers.
+	sb            uint8
+	transfer      bool
+	internalClock bool
+
+	// Byte being shifted in, and how many bits are left to shift.
+	in   uint8
+	bits uint8
+
+	// Clocks until the next bit is shifted.
+	timer uint16
+
+	// Clocks until the peer is next polled.
+	pollTimer uint16
+}
+
+func NewSerial() *Serial {
+	return &Serial{
+		pollTimer: BitClocks,
+	}
+}
+
+// Do a serial step. Consumes 1 clock.
+func (s *Serial) Step() {
+	// Let the peer clock bytes in, whether or not a transfer is in progress.
+	s.pollTimer--
+	if s.pollTimer == 0 {
+		s.pollTimer = BitClocks
+		if s.peer != nil {
+			s.peer.Poll(s)
+		}
+	}
+
+	if !s.transfer || !s.internalClock {
+		return
+	}
+	if s.timer > 0 {
+		s.timer--
+	}
+	if s.timer > 0 {
+		return
+	}
+	s.timer = BitClocks
+
+	// Shift a bit in from the byte received from the peer.
+	s.sb = s.sb<<1 | s.in>>7
+	s.in <<= 1
+	s.bits--
+	if s.bits == 0 {
+		s.complete()
+	}
+}
+
+// Clock a byte in from a peer that provides the clock. Returns the byte shifted out in response.
+// If this side is not waiting for a transfer with the external clock, nothing is shifted, and the
+// peer receives 0xFF.
+func (s *Serial) Receive(in uint8) uint8 {
+	if !s.transfer || s.internalClock {
+		return 0xff
+	}
+	out := s.sb
+	s.sb = in
+	s.complete()
+	return out
+}
+
+// Start a transfer.
+func (s *Serial) start() {
+	s.timer = BitClocks
+	if !s.internalClock {
+		return
+	}
+
+	// This side provides the clock, so exchange the whole byte with the peer up front. With no
+	// peer attached, the input line is pulled high.
+	s.bits = 8
+	s.in = 0xff
+	if s.peer != nil {
+		s.in = s.peer.Exchange(s, s.sb)
+	}
+}
+
+// Complete a transfer.
+func (s *Serial) complete() {
+	s.transfer = false
+	s.interruptSerial()
+}
+
+// Attach a link cable peer. Pass nil to disconnect the cable.
+func (s *Serial) AttachPeer(peer LinkPeer) {
+	s.peer = peer
+}
+
+// Attach an MMU.
+func (s *Serial) AttachMMU(mmu MMU) {
+	s.mmu = mmu
+}
+
+// Get the SB register.
+func (s *Serial) SB() uint8 {
+	return s.sb
+}
+
+// Set the SB register.
+func (s *Serial) SetSB(v uint8) {
+	s.sb = v
+}
+
+// Get the SC register.
+func (s *Serial) SC() uint8 {
+	sc := uint8(0x7e) // Unused bits always read 1.
+	sc = utils.SetBit(sc, FlagTransfer, s.transfer)
+	sc = utils.SetBit(sc, FlagInternalClock, s.internalClock)
+	return sc
+}
+
+// Set the SC register.
+func (s *Serial) SetSC(v uint8) {
+	s.transfer = utils.GetBit(v, FlagTransfer)
+	s.internalClock = utils.GetBit(v, FlagInternalClock)
+	if s.transfer {
+		s.start()
+	}
+}
@@ -0,0 +1,52 @@
+package serial
+
+import (
+	"encoding/binary"
+	"io"
+)
+
+// Serialized serial port state. The peer is not part of the state.
+type serialState struct {
+	SB            uint8
+	Transfer      bool
+	InternalClock bool
+	In            uint8
+	Bits          uint8
+	Timer         uint16
+	PollTimer     uint16
+}
+
+// Write the serial port state.
+func (s *Serial) SaveState(w io.Writer) error {
+	st := serialState{
+		SB:            s.sb,
+		Transfer:      s.transfer,
+		InternalClock: s.internalClock,
+		In:            s.in,
+		Bits:          s.bits,
+		Timer:         s.timer,
+		PollTimer:     s.pollTimer,
+	}
+	return binary.Write(w, binary.LittleEndian, &st)
+}
+
+// Read the serial port state.
+func (s *Serial) LoadState(r io.Reader) error {
+	var st serialState
+	err := binary.Read(r, binary.LittleEndian, &st)
+	if err != nil {
+		return err
+	}
+
+	s.sb = st.SB
+	s.transfer = st.Transfer
+	s.internalClock = st.InternalClock
+	s.in = st.In
+	s.bits = st.Bits
+	s.timer = st.Timer
+	s.pollTimer = st.PollTimer
+	if s.pollTimer == 0 || s.pollTimer > BitClocks {
+		s.pollTimer = BitClocks
+	}
+	return nil
+}
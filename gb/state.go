@@ -0,0 +1,121 @@
+package gb
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// Save state format constants. The version must be incremented whenever the serialized state of
+// any component changes, so that old states are rejected instead of loaded incorrectly.
+const (
+	StateMagic   = "GBSS"
+	StateVersion = 11
+)
+
+// Save state header.
+type stateHeader struct {
+	Magic   [4]uint8
+	Version uint32
+
+	// Which optional components are in the state.
+	HasBootROM   bool
+	HasCartridge bool
+
+	// Game Boy state.
+	Cycles    uint64
+	ClockDebt int64
+}
+
+// Write the state of the whole machine. The Game Boy should be paused while saving.
+func (gb *GameBoy) SaveState(w io.Writer) error {
+	h := stateHeader{
+		Version:      StateVersion,
+		HasBootROM:   gb.boot != nil,
+		HasCartridge: gb.cart != nil,
+		Cycles:       gb.cycles,
+		ClockDebt:    int64(gb.clockDebt),
+	}
+	copy(h.Magic[:], StateMagic)
+	err := binary.Write(w, binary.LittleEndian, &h)
+	if err != nil {
+		return err
+	}
+
+	// Write each of the components.
+	components := []interface{ SaveState(io.Writer) error }{gb.cpu, gb.ppu, gb.apu, gb.mmu, gb.jp, gb.sio}
+	if gb.boot != nil {
+		components = append(components, gb.boot)
+	}
+	if gb.cart != nil {
+		components = append(components, gb.cart)
+	}
+	for _, component := range components {
+		err = component.SaveState(w)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Read the state of the whole machine. The Game Boy must have the same boot ROM and cartridge
+// setup as when the state was saved. If loading fails, the Game Boy is left unchanged. The Game
+// Boy should be paused while loading.
+func (gb *GameBoy) LoadState(r io.Reader) error {
+	var h stateHeader
+	err := binary.Read(r, binary.LittleEndian, &h)
+	if err != nil {
+		return err
+	}
+	if string(h.Magic[:]) != StateMagic {
+		return fmt.Errorf("Not a save state")
+	}
+	if h.Version != StateVersion {
+		return fmt.Errorf("Unsupported save state version: %v (expected %v)", h.Version, StateVersion)
+	}
+	if h.HasBootROM != (gb.boot != nil) {
+		return fmt.Errorf("Save state boot ROM mismatch")
+	}
+	if h.HasCartridge != (gb.cart != nil) {
+		return fmt.Errorf("Save state cartridge mismatch")
+	}
+
+	// Back up the current state so that a failed load does not leave the machine half-loaded.
+	var backup bytes.Buffer
+	err = gb.SaveState(&backup)
+	if err != nil {
+		return err
+	}
+
+	err = gb.loadComponents(r)
+	if err != nil {
+		// Restoring the backup skips its header, since it is known to be good.
+		binary.Read(&backup, binary.LittleEndian, &stateHeader{})
+		gb.loadComponents(&backup)
+		return err
+	}
+
+	gb.cycles = h.Cycles
+	gb.clockDebt = int(h.ClockDebt)
+	return nil
+}
+
+// Read the state of each component.
+func (gb *GameBoy) loadComponents(r io.Reader) error {
+	components := []interface{ LoadState(io.Reader) error }{gb.cpu, gb.ppu, gb.apu, gb.mmu, gb.jp, gb.sio}
+	if gb.boot != nil {
+		components = append(components, gb.boot)
+	}
+	if gb.cart != nil {
+		components = append(components, gb.cart)
+	}
+	for _, component := range components {
+		err := component.LoadState(r)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+package gb
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ruiqimao/go-gb-emu/cart"
+)
+
+// Environment variable holding the directory of test ROMs and reference images. Tests that need
+// them are skipped if it is not set.
+const testROMsEnv = "GB_TEST_ROMS"
+
+// Get the path of a file in the test ROM directory. Skips the test if the file is missing.
+func testFilePath(t *testing.T, name string) string {
+	t.Helper()
+	dir := os.Getenv(testROMsEnv)
+	if dir == "" {
+		t.Skipf("%v is not set", testROMsEnv)
+	}
+	path := filepath.Join(dir, filepath.FromSlash(name))
+	if _, err := os.Stat(path); err != nil {
+		t.Skip(err)
+	}
+	return path
+}
+
+// Make a headless DMG running a test ROM, starting from the state the boot ROM leaves.
+func newTestROMGameBoy(t *testing.T, name string) *GameBoy {
+	t.Helper()
+	rom, err := ioutil.ReadFile(testFilePath(t, name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	gb, err := NewGameBoy(Headless())
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := cart.NewCartridge(rom)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gb.LoadCartridge(c)
+	err = gb.SkipBoot(ModelDMG)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return gb
+}
@@ -0,0 +1,120 @@
+package gbs
+
+import (
+	"encoding/binary"
+	"fmt"
+	"strings"
+
+	"github.com/ruiqimao/go-gb-emu/utils"
+)
+
+// GBS header layout.
+// Documented at https://ocremix.org/info/GBS_Format_Specification.
+const (
+	AddrMagic        = 0x00
+	AddrVersion      = 0x03
+	AddrSongs        = 0x04
+	AddrFirstSong    = 0x05
+	AddrLoad         = 0x06
+	AddrInit         = 0x08
+	AddrPlay         = 0x0a
+	AddrStackPointer = 0x0c
+	AddrTMA          = 0x0e
+	AddrTAC          = 0x0f
+	AddrTitle        = 0x10
+	AddrAuthor       = 0x30
+	AddrCopyright    = 0x50
+	AddrCode         = 0x70
+)
+
+// GBS header constants.
+const (
+	Magic   = "GBS"
+	Version = 1
+
+	// Code is loaded above the driver.
+	MinLoadAddr = 0x0400
+
+	// Flags in the TAC field.
+	FlagTimerEnable = 2
+)
+
+// Timer frequencies selected by TAC, in Hz.
+var timerFrequencies = [4]float64{4096, 262144, 65536, 16384}
+
+// GBS file header.
+type Header struct {
+	Version   uint8
+	Songs     uint8
+	FirstSong uint8 // Songs are numbered from 1.
+
+	LoadAddr     uint16
+	InitAddr     uint16
+	PlayAddr     uint16
+	StackPointer uint16
+
+	TMA uint8
+	TAC uint8
+
+	Title     string
+	Author    string
+	Copyright string
+}
+
+// Parse the header of a GBS file.
+func ParseHeader(data []uint8) (*Header, error) {
+	if len(data) < AddrCode {
+		return nil, fmt.Errorf("GBS file too small: %v", len(data))
+	}
+	if string(data[AddrMagic:AddrVersion]) != Magic {
+		return nil, fmt.Errorf("Not a GBS file")
+	}
+
+	h := &Header{
+		Version:      data[AddrVersion],
+		Songs:        data[AddrSongs],
+		FirstSong:    data[AddrFirstSong],
+		LoadAddr:     binary.LittleEndian.Uint16(data[AddrLoad:]),
+		InitAddr:     binary.LittleEndian.Uint16(data[AddrInit:]),
+		PlayAddr:     binary.LittleEndian.Uint16(data[AddrPlay:]),
+		StackPointer: binary.LittleEndian.Uint16(data[AddrStackPointer:]),
+		TMA:          data[AddrTMA],
+		TAC:          data[AddrTAC],
+		Title:        headerString(data[AddrTitle:AddrAuthor]),
+		Author:       headerString(data[AddrAuthor:AddrCopyright]),
+		Copyright:    headerString(data[AddrCopyright:AddrCode]),
+	}
+
+	if h.Version != Version {
+		return nil, fmt.Errorf("Unsupported GBS version: %v", h.Version)
+	}
+	if h.Songs == 0 {
+		return nil, fmt.Errorf("GBS file has no songs")
+	}
+	if h.LoadAddr < MinLoadAddr || h.LoadAddr >= 0x8000 {
+		return nil, fmt.Errorf("Improper GBS load address: %04x", h.LoadAddr)
+	}
+	if h.InitAddr >= 0x8000 || h.PlayAddr >= 0x8000 {
+		return nil, fmt.Errorf("Improper GBS routine address: init %04x, play %04x", h.InitAddr, h.PlayAddr)
+	}
+
+	return h, nil
+}
+
+// Get whether PLAY is called by the timer interrupt instead of VBlank.
+func (h *Header) UsesTimer() bool {
+	return utils.GetBit(h.TAC, FlagTimerEnable)
+}
+
+// Get how many times per second PLAY is called.
+func (h *Header) PlayRate() float64 {
+	if !h.UsesTimer() {
+		return VBlankRate
+	}
+	return timerFrequencies[h.TAC&0x3] / float64(256-int(h.TMA))
+}
+
+// Convert a NUL padded header field to a string.
+func headerString(data []uint8) string {
+	return strings.TrimRight(string(data), "\x00")
+}
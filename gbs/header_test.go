@@ -0,0 +1,131 @@
+package gbs
+
+import (
+	"encoding/binary"
+	"math"
+	"testing"
+)
+
+// Make a GBS file with 2 songs, loaded at 0400 with INIT at 0400 and PLAY at 0410. INIT stores A
+// at C000, and PLAY counts its calls at C001.
+func testGBS(tac, tma uint8) []uint8 {
+	data := make([]uint8, AddrCode+0x100)
+	copy(data[AddrMagic:], Magic)
+	data[AddrVersion] = Version
+	data[AddrSongs] = 2
+	data[AddrFirstSong] = 1
+	binary.LittleEndian.PutUint16(data[AddrLoad:], 0x0400)
+	binary.LittleEndian.PutUint16(data[AddrInit:], 0x0400)
+	binary.LittleEndian.PutUint16(data[AddrPlay:], 0x0410)
+	binary.LittleEndian.PutUint16(data[AddrStackPointer:], 0xfffe)
+	data[AddrTMA] = tma
+	data[AddrTAC] = tac
+	copy(data[AddrTitle:], "Title")
+	copy(data[AddrAuthor:], "Author")
+	copy(data[AddrCopyright:], "2000 Copyright")
+
+	code := data[AddrCode:]
+	copy(code, []uint8{
+		0xea, 0x00, 0xc0, // ld ($c000), a
+		0xc9, // ret
+	})
+	copy(code[0x10:], []uint8{
+		0x21, 0x01, 0xc0, // ld hl, $c001
+		0x34, // inc (hl)
+		0xc9, // ret
+	})
+	return data
+}
+
+func TestParseHeader(t *testing.T) {
+	h, err := ParseHeader(testGBS(0x00, 0x00))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := Header{
+		Version:      1,
+		Songs:        2,
+		FirstSong:    1,
+		LoadAddr:     0x0400,
+		InitAddr:     0x0400,
+		PlayAddr:     0x0410,
+		StackPointer: 0xfffe,
+		Title:        "Title",
+		Author:       "Author",
+		Copyright:    "2000 Copyright",
+	}
+	if *h != expected {
+		t.Errorf("Got %+v, expected %+v", *h, expected)
+	}
+}
+
+func TestParseHeaderErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func([]uint8) []uint8
+	}{
+		{"Empty", func(d []uint8) []uint8 { return nil }},
+		{"TruncatedMagic", func(d []uint8) []uint8 { return d[:AddrVersion] }},
+		{"TruncatedHeader", func(d []uint8) []uint8 { return d[:AddrCode-1] }},
+		{"Magic", func(d []uint8) []uint8 { d[AddrMagic] = 'N'; return d }},
+		{"Version", func(d []uint8) []uint8 { d[AddrVersion] = 2; return d }},
+		{"NoSongs", func(d []uint8) []uint8 { d[AddrSongs] = 0; return d }},
+		{"LoadInDriver", func(d []uint8) []uint8 {
+			binary.LittleEndian.PutUint16(d[AddrLoad:], MinLoadAddr-1)
+			return d
+		}},
+		{"LoadInRAM", func(d []uint8) []uint8 {
+			binary.LittleEndian.PutUint16(d[AddrLoad:], 0x8000)
+			return d
+		}},
+		{"InitInRAM", func(d []uint8) []uint8 {
+			binary.LittleEndian.PutUint16(d[AddrInit:], 0xc000)
+			return d
+		}},
+		{"PlayInRAM", func(d []uint8) []uint8 {
+			binary.LittleEndian.PutUint16(d[AddrPlay:], 0x8000)
+			return d
+		}},
+	}
+	for _, test := range tests {
+		if _, err := ParseHeader(test.modify(testGBS(0x00, 0x00))); err == nil {
+			t.Errorf("%v: parsed", test.name)
+		}
+	}
+}
+
+func TestPlayRate(t *testing.T) {
+	tests := []struct {
+		tac   uint8
+		tma   uint8
+		timer bool
+		rate  float64
+	}{
+		// Without the timer enabled, PLAY is called on VBlank.
+		{0x00, 0x00, false, VBlankRate},
+		{0x03, 0xc0, false, VBlankRate},
+
+		// With the timer, PLAY is called each time TIMA overflows.
+		{0x04, 0x00, true, 4096.0 / 256},
+		{0x05, 0x00, true, 262144.0 / 256},
+		{0x06, 0xc0, true, 65536.0 / 64},
+		{0x07, 0xff, true, 16384.0},
+	}
+	for _, test := range tests {
+		h, err := ParseHeader(testGBS(test.tac, test.tma))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if h.TAC != test.tac || h.TMA != test.tma {
+			t.Errorf("Got TAC %02x and TMA %02x, expected %02x and %02x", h.TAC, h.TMA, test.tac,
+				test.tma)
+		}
+		if h.UsesTimer() != test.timer {
+			t.Errorf("TAC %02x: timer %v, expected %v", test.tac, h.UsesTimer(), test.timer)
+		}
+		if rate := h.PlayRate(); math.Abs(rate-test.rate) > 1e-9 {
+			t.Errorf("TAC %02x, TMA %02x: rate %v, expected %v", test.tac, test.tma, rate,
+				test.rate)
+		}
+	}
+}
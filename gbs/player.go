@@ -0,0 +1,137 @@
+package gbs
+
+import (
+	"fmt"
+
+	"github.com/ruiqimao/go-gb-emu/gb/apu"
+	"github.com/ruiqimao/go-gb-emu/gb/cpu"
+	"github.com/ruiqimao/go-gb-emu/gb/mmu"
+)
+
+// Timing constants.
+const (
+	CPUClock     = 4194304
+	VBlankClocks = 70224 // Clocks between VBlank interrupts.
+	VBlankRate   = float64(CPUClock) / VBlankClocks
+)
+
+// Interrupts.
+const (
+	InterruptVBlank = 0
+	InterruptTimer  = cpu.InterruptTimer
+)
+
+// Plays GBS files on a minimal Game Boy with only a CPU, an MMU, and an APU. There is no PPU, so
+// VBlank interrupts are generated by the player.
+type Player struct {
+	header *Header
+
+	cpu *cpu.CPU
+	mmu *mmu.MMU
+	apu *apu.APU
+	rom *rom
+
+	// Clocks until the next VBlank interrupt.
+	vblankClocks int
+
+	// Audio samples.
+	S chan []apu.Sample
+}
+
+func NewPlayer(data []uint8, sampleRate int) (*Player, error) {
+	header, err := ParseHeader(data)
+	if err != nil {
+		return nil, err
+	}
+	if sampleRate <= 0 {
+		return nil, fmt.Errorf("Invalid sample rate: %v", sampleRate)
+	}
+
+	p := &Player{
+		header: header,
+		rom:    newROM(header, data),
+		apu:    apu.NewAPU(sampleRate),
+	}
+	p.S = p.apu.S
+	return p, nil
+}
+
+// Get the GBS header.
+func (p *Player) Header() *Header {
+	return p.header
+}
+
+// Attach a recorder to the APU, or detach it with nil.
+func (p *Player) Record(recorder apu.Recorder) {
+	p.apu.AttachRecorder(recorder)
+}
+
+// Start playing a song. Songs are numbered from 1. The machine is reset, and INIT is called with
+// the song index in A.
+func (p *Player) Start(song int) error {
+	if song < 1 || song > int(p.header.Songs) {
+		return fmt.Errorf("Song %v out of range 1 - %v", song, p.header.Songs)
+	}
+
+	// Build a fresh machine. The APU is kept so that its output stream continues.
+	p.cpu = cpu.NewCPU()
+	p.mmu = mmu.NewMMU()
+	p.rom.bank = 1
+	p.rom.ram = [RAMSize]uint8{}
+
+	p.cpu.AttachMMU(p.mmu.CPUBus())
+	p.apu.AttachMMU(p.mmu.APUBus())
+
+	p.mmu.AttachCPU(p.cpu)
+	p.mmu.AttachAPU(p.apu)
+	p.mmu.AttachCartridge(p.rom)
+
+	// Reset the APU by cycling its power, and turn on all of the outputs.
+	bus := p.mmu.CPUBus()
+	bus.Write(mmu.AddrNR52, 0x00)
+	bus.Write(mmu.AddrNR52, 0x80)
+	bus.Write(mmu.AddrNR50, 0x77)
+	bus.Write(mmu.AddrNR51, 0xff)
+
+	// Set up the timer.
+	bus.Write(mmu.AddrTMA, p.header.TMA)
+	bus.Write(mmu.AddrTAC, p.header.TAC)
+
+	// Call INIT through the driver.
+	p.cpu.SetRegister(cpu.RegisterA, uint8(song-1))
+	p.cpu.SetSP(p.header.StackPointer)
+	p.cpu.SetPC(AddrEntry)
+	p.vblankClocks = VBlankClocks
+
+	return nil
+}
+
+// Run a number of clocks. Returns how many extra clocks above the given limit were taken. If the
+// CPU faults, this stops early and returns the fault.
+func (p *Player) RunClocks(limit int) (int, error) {
+	if p.cpu == nil {
+		return 0, fmt.Errorf("No song started")
+	}
+
+	for limit > 0 {
+		clocks, err := p.cpu.Step()
+		limit -= clocks
+
+		for i := 0; i < clocks; i++ {
+			p.apu.Step()
+			p.mmu.Step()
+
+			// Generate VBlank interrupts in place of the PPU.
+			p.vblankClocks--
+			if p.vblankClocks == 0 {
+				p.vblankClocks = VBlankClocks
+				p.cpu.RequestInterrupt(InterruptVBlank)
+			}
+		}
+
+		if err != nil {
+			return -limit, err
+		}
+	}
+	return -limit, nil
+}
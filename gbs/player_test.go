@@ -0,0 +1,60 @@
+package gbs
+
+import (
+	"testing"
+)
+
+// Play a song for a second. Returns the song index INIT was called with, and how many times PLAY
+// was called.
+func playSecond(t *testing.T, p *Player, song int) (uint8, uint8) {
+	t.Helper()
+	err := p.Start(song)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = p.RunClocks(CPUClock)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bus := p.mmu.CPUBus()
+	return bus.Read(0xc000), bus.Read(0xc001)
+}
+
+func TestPlayer(t *testing.T) {
+	tests := []struct {
+		tac  uint8
+		rate int
+	}{
+		{0x00, 60}, // VBlank.
+		{0x04, 16}, // Timer at 4096Hz / 256.
+	}
+	for _, test := range tests {
+		p, err := NewPlayer(testGBS(test.tac, 0x00), 44100)
+		if err != nil {
+			t.Fatal(err)
+		}
+		index, plays := playSecond(t, p, 2)
+		if index != 1 {
+			t.Errorf("TAC %02x: INIT called with %v, expected 1", test.tac, index)
+		}
+		if int(plays) < test.rate-1 || int(plays) > test.rate+1 {
+			t.Errorf("TAC %02x: PLAY called %v times, expected about %v", test.tac, plays,
+				test.rate)
+		}
+	}
+}
+
+func TestPlayerSongRange(t *testing.T) {
+	p, err := NewPlayer(testGBS(0x00, 0x00), 44100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, song := range []int{0, 3} {
+		if err := p.Start(song); err == nil {
+			t.Errorf("Started song %v of 2", song)
+		}
+	}
+	if _, err := p.RunClocks(1); err == nil {
+		t.Error("Ran without a song started")
+	}
+}
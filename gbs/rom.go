@@ -0,0 +1,134 @@
+package gbs
+
+import (
+	"encoding/binary"
+)
+
+// Driver layout. The driver fills the space below the load address, which would normally hold
+// the RST and interrupt vectors and the cartridge header.
+const (
+	AddrVBlankVector = 0x0040
+	AddrTimerVector  = 0x0050
+	AddrEntry        = 0x0100 // Calls INIT, enables interrupts, then idles.
+	AddrIdle         = 0x010b // Halts until the next interrupt calls PLAY.
+)
+
+// Driver op codes.
+const (
+	opJP   = 0xc3
+	opCALL = 0xcd
+	opRETI = 0xd9
+	opLDA  = 0x3e
+	opLDH  = 0xe0
+	opXORA = 0xaf
+	opEI   = 0xfb
+	opHALT = 0x76
+	opJR   = 0x18
+)
+
+// Sizes.
+const (
+	ROMBankSize = 0x4000
+	RAMSize     = 0x2000
+)
+
+// GBS ROM image with a simple bank switcher, as seen by the MMU. Writes to 2000 - 3FFF select the
+// bank mapped at 4000 - 7FFF, and RAM is always enabled.
+type rom struct {
+	data []uint8
+	bank int
+	ram  [RAMSize]uint8
+}
+
+// Build the ROM image for a GBS file, with the driver below the load address.
+func newROM(h *Header, data []uint8) *rom {
+	code := data[AddrCode:]
+	size := int(h.LoadAddr) + len(code)
+	size = (size + ROMBankSize - 1) / ROMBankSize * ROMBankSize
+	if size < 2*ROMBankSize {
+		size = 2 * ROMBankSize
+	}
+
+	r := &rom{
+		data: make([]uint8, size),
+		bank: 1,
+	}
+	copy(r.data[h.LoadAddr:], code)
+
+	// RST vectors jump to the same offset from the load address.
+	for rst := uint16(0x00); rst < AddrVBlankVector; rst += 0x08 {
+		r.writeOp(rst, opJP, h.LoadAddr+rst)
+	}
+
+	// Interrupt vectors return immediately, except for the one that calls PLAY.
+	for vector := uint16(AddrVBlankVector); vector <= 0x0060; vector += 0x08 {
+		r.data[vector] = opRETI
+	}
+	playVector := uint16(AddrVBlankVector)
+	if h.UsesTimer() {
+		playVector = AddrTimerVector
+	}
+	addr := r.writeOp(playVector, opCALL, h.PlayAddr)
+	r.data[addr] = opRETI
+
+	// Call INIT, then enable only the interrupt that calls PLAY. Any interrupt INIT requested is
+	// cleared first.
+	interrupt := uint8(0x1 << InterruptVBlank)
+	if h.UsesTimer() {
+		interrupt = 0x1 << InterruptTimer
+	}
+	addr = r.writeOp(AddrEntry, opCALL, h.InitAddr)
+	addr = r.writeBytes(addr, opLDA, interrupt)
+	addr = r.writeBytes(addr, opLDH, 0xff) // IE
+	addr = r.writeBytes(addr, opXORA)
+	addr = r.writeBytes(addr, opLDH, 0x0f) // IF
+	addr = r.writeBytes(addr, opEI)
+
+	// Idle until the next interrupt.
+	r.writeBytes(AddrIdle, opHALT, opJR, 0xfd)
+
+	return r
+}
+
+// Write an op code with a 16-bit operand. Returns the address after it.
+func (r *rom) writeOp(addr uint16, op uint8, operand uint16) uint16 {
+	r.data[addr] = op
+	binary.LittleEndian.PutUint16(r.data[addr+1:], operand)
+	return addr + 3
+}
+
+// Write bytes. Returns the address after them.
+func (r *rom) writeBytes(addr uint16, data ...uint8) uint16 {
+	copy(r.data[addr:], data)
+	return addr + uint16(len(data))
+}
+
+func (r *rom) ReadROM(addr uint16) uint8 {
+	if addr < ROMBankSize {
+		return r.data[addr]
+	}
+	offset := r.bank*ROMBankSize + int(addr-ROMBankSize)
+	return r.data[offset%len(r.data)]
+}
+
+func (r *rom) ReadRAM(addr uint16) uint8 {
+	return r.ram[addr]
+}
+
+func (r *rom) WriteROM(addr uint16, v uint8) {
+	if addr >= 0x2000 && addr < 0x4000 {
+		// Bank 0 is remapped to bank 1.
+		r.bank = int(v)
+		if r.bank == 0 {
+			r.bank = 1
+		}
+	}
+}
+
+func (r *rom) WriteRAM(addr uint16, v uint8) {
+	r.ram[addr] = v
+}
+
+func (r *rom) RAMEnabled() bool {
+	return true
+}
@@ -0,0 +1,96 @@
+package gbs
+
+import (
+	"bytes"
+	"testing"
+)
+
+// Make the ROM image of a GBS file.
+func testROM(t *testing.T, data []uint8) *rom {
+	t.Helper()
+	h, err := ParseHeader(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return newROM(h, data)
+}
+
+// Check the bytes at an address.
+func checkBytes(t *testing.T, r *rom, addr uint16, expected ...uint8) {
+	t.Helper()
+	got := r.data[addr : int(addr)+len(expected)]
+	if !bytes.Equal(got, expected) {
+		t.Errorf("%04x: got % x, expected % x", addr, got, expected)
+	}
+}
+
+func TestDriver(t *testing.T) {
+	tests := []struct {
+		tac        uint8
+		playVector uint16
+		ie         uint8
+	}{
+		{0x00, AddrVBlankVector, 0x01},
+		{0x04, AddrTimerVector, 0x04},
+	}
+	for _, test := range tests {
+		data := testGBS(test.tac, 0x00)
+		r := testROM(t, data)
+
+		// The code is loaded at the load address.
+		checkBytes(t, r, 0x0400, data[AddrCode:]...)
+
+		// RST vectors jump to the same offset from the load address.
+		for rst := uint16(0x00); rst < AddrVBlankVector; rst += 0x08 {
+			checkBytes(t, r, rst, opJP, uint8(rst), 0x04)
+		}
+
+		// The interrupt that calls PLAY calls it and returns, and the rest return immediately.
+		for vector := uint16(AddrVBlankVector); vector <= 0x0060; vector += 0x08 {
+			if vector == test.playVector {
+				checkBytes(t, r, vector, opCALL, 0x10, 0x04, opRETI)
+			} else {
+				checkBytes(t, r, vector, opRETI)
+			}
+		}
+
+		// The entry point calls INIT, enables the PLAY interrupt and falls through to idling.
+		checkBytes(t, r, AddrEntry,
+			opCALL, 0x00, 0x04,
+			opLDA, test.ie,
+			opLDH, 0xff,
+			opXORA,
+			opLDH, 0x0f,
+			opEI,
+		)
+		checkBytes(t, r, AddrIdle, opHALT, opJR, 0xfd)
+	}
+}
+
+func TestROMBanks(t *testing.T) {
+	// Small files are padded to 2 banks, and larger ones to a whole number of banks.
+	data := testGBS(0x00, 0x00)
+	if r := testROM(t, data); len(r.data) != 2*ROMBankSize {
+		t.Errorf("ROM size %04x, expected %04x", len(r.data), 2*ROMBankSize)
+	}
+	data = append(data, make([]uint8, 2*ROMBankSize)...)
+	data[len(data)-1] = 0x5a
+	r := testROM(t, data)
+	if len(r.data) != 3*ROMBankSize {
+		t.Fatalf("ROM size %04x, expected %04x", len(r.data), 3*ROMBankSize)
+	}
+
+	// The last byte of the code is in bank 2, offset by the load address.
+	last := 0x0400 + len(data) - AddrCode - 1
+	bank := last / ROMBankSize
+	r.WriteROM(0x2000, uint8(bank))
+	if v := r.ReadROM(uint16(ROMBankSize + last%ROMBankSize)); v != 0x5a {
+		t.Errorf("Read %02x from bank %v", v, bank)
+	}
+
+	// Bank 0 is mapped to bank 1.
+	r.WriteROM(0x2000, 0x00)
+	if r.bank != 1 {
+		t.Errorf("Selected bank %v, expected 1", r.bank)
+	}
+}
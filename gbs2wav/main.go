@@ -0,0 +1,68 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"strconv"
+
+	"github.com/ruiqimao/go-gb-emu/gb/apu"
+	"github.com/ruiqimao/go-gb-emu/gbs"
+)
+
+// Renders a song from a GBS file to a WAV file.
+func main() {
+	if len(os.Args) < 5 || len(os.Args) > 6 || (len(os.Args) == 6 && os.Args[5] != "channels") {
+		fmt.Fprintf(os.Stderr, "Usage: %v <file.gbs> <song> <seconds> <out.wav> [channels]\n", os.Args[0])
+		os.Exit(1)
+	}
+
+	song, err := strconv.Atoi(os.Args[2])
+	if err != nil {
+		log.Fatal(err)
+	}
+	seconds, err := strconv.ParseFloat(os.Args[3], 64)
+	if err != nil {
+		log.Fatal(err)
+	}
+	perChannel := len(os.Args) == 6
+
+	data, err := ioutil.ReadFile(os.Args[1])
+	if err != nil {
+		log.Fatal(err)
+	}
+	err = render(data, song, seconds, os.Args[4], perChannel)
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+func render(data []uint8, song int, seconds float64, path string, perChannel bool) error {
+	player, err := gbs.NewPlayer(data, apu.DefaultSampleRate)
+	if err != nil {
+		return err
+	}
+	h := player.Header()
+	fmt.Printf("%v - %v (%v)\n", h.Title, h.Author, h.Copyright)
+	fmt.Printf("Song %v of %v, PLAY called at %.2fHz\n", song, h.Songs, h.PlayRate())
+
+	err = player.Start(song)
+	if err != nil {
+		return err
+	}
+
+	recorder, err := apu.NewWAVRecorder(path, apu.DefaultSampleRate, perChannel)
+	if err != nil {
+		return err
+	}
+	player.Record(recorder)
+
+	// Samples are taken from the recorder, so the sample stream is left to drop them.
+	_, err = player.RunClocks(int(seconds * gbs.CPUClock))
+	if err != nil {
+		recorder.Close()
+		return err
+	}
+	return recorder.Close()
+}
@@ -11,6 +11,8 @@ import (
 
 	"github.com/ruiqimao/go-gb-emu/gb/cpu"
 	"github.com/ruiqimao/go-gb-emu/gb/ppu"
+	"github.com/ruiqimao/go-gb-emu/gb/printer"
+	"github.com/ruiqimao/go-gb-emu/gb/serial"
 	"github.com/ruiqimao/go-gfx/gfx"
 )
 
@@ -130,7 +132,7 @@ func (e *Emulator) debugExec(input []string) {
 				data := lo | hi<<1
 
 				// Convert to a Pixel and resolve the color
-				px := ppu.NewPixel(data, true, false, false)
+				px := ppu.NewPixel(data, true, 0, false)
 				color := gbPPU.Resolve(px)
 
 				// Print the color as a block.
@@ -162,7 +164,12 @@ func (e *Emulator) debugExec(input []string) {
 
 		cycles := 0
 		for i := 0; i < steps; i++ {
-			cycles += e.gb.Step()
+			n, fault := e.gb.Step()
+			cycles += n
+			if fault != nil {
+				fmt.Fprintf(os.Stderr, "%v\n", fault)
+				break
+			}
 		}
 		fmt.Printf("%d cycles\n", cycles)
 
@@ -177,16 +184,115 @@ func (e *Emulator) debugExec(input []string) {
 			break
 		}
 
-		cycles := e.gb.Step()
+		cycles := 0
 		for {
-			if gbCPU.PC() == addr {
+			// Stop early if the CPU faults or is locked up, since it will never reach the address.
+			n, fault := e.gb.Step()
+			cycles += n
+			if fault != nil {
+				fmt.Fprintf(os.Stderr, "%v\n", fault)
+				break
+			}
+			if gbCPU.Locked() {
+				fmt.Fprintf(os.Stderr, "CPU is locked up\n")
 				break
 			}
 
-			cycles += e.gb.Step()
+			if gbCPU.PC() == addr {
+				break
+			}
 		}
 		fmt.Printf("%d cycles\n", cycles)
 
+	// Save the machine state to a file.
+	case "save":
+		if len(input) < 2 {
+			fmt.Printf("Usage: save <file>\n")
+			break
+		}
+		var f *os.File
+		f, err = os.Create(input[1])
+		if err != nil {
+			break
+		}
+		running := e.gb.Pause()
+		err = e.gb.SaveState(f)
+		if running {
+			e.gb.Resume()
+		}
+		f.Close()
+
+	// Load the machine state from a file.
+	case "load":
+		if len(input) < 2 {
+			fmt.Printf("Usage: load <file>\n")
+			break
+		}
+		var f *os.File
+		f, err = os.Open(input[1])
+		if err != nil {
+			break
+		}
+		running := e.gb.Pause()
+		err = e.gb.LoadState(f)
+		if running {
+			e.gb.Resume()
+		}
+		f.Close()
+
+	// Print bytes sent over the serial port. Test ROMs print their results this way.
+	case "serial":
+		err = e.closeLink()
+		e.gb.AttachLinkPeer(serial.NewWriter(os.Stdout))
+
+	// Connect a Game Boy Printer that saves printed images to a directory.
+	case "printer":
+		if len(input) < 2 {
+			fmt.Printf("Usage: printer <directory>\n")
+			break
+		}
+		err = e.closeLink()
+		e.gb.AttachLinkPeer(printer.NewPrinter(printer.SavePNG(input[1])))
+
+	// Connect a link cable to another emulator instance.
+	case "link":
+		if len(input) == 2 && input[1] == "close" {
+			err = e.closeLink()
+			e.gb.AttachLinkPeer(nil)
+			break
+		}
+		if len(input) < 4 || (input[1] != "listen" && input[1] != "dial") {
+			fmt.Printf("Usage: link <listen|dial> <tcp|unix> <address>\n")
+			fmt.Printf("       link close\n")
+			break
+		}
+		var link *serial.Link
+		if input[1] == "listen" {
+			fmt.Printf("Waiting for connection on %v...\n", input[3])
+			link, err = serial.ListenLink(input[2], input[3])
+		} else {
+			link, err = serial.DialLink(input[2], input[3])
+		}
+		if err != nil {
+			break
+		}
+		err = e.closeLink()
+		e.link = link
+		e.gb.AttachLinkPeer(link)
+
+	// Record audio to a WAV file, optionally with each channel in its own file.
+	case "record", "rec":
+		if len(input) < 2 {
+			fmt.Printf("Usage: record <file.wav> [channels]\n")
+			break
+		}
+		perChannel := len(input) >= 3 && input[2] == "channels"
+		err = e.gb.StartRecording(input[1], perChannel)
+
+	// Stop recording audio.
+	case "stoprecord", "srec":
+		err = e.gb.StopRecording()
+
 	// Run.
 	case "run", "r":
 		e.gb.Resume()
@@ -205,6 +311,18 @@ func (e *Emulator) debugExec(input []string) {
 	}
 }
 
+// Close the link cable, if there is one. This must be done before attaching another link peer,
+// since the emulation loop may be waiting on the other side of the link and would never pick up
+// the new peer.
+func (e *Emulator) closeLink() error {
+	if e.link == nil {
+		return nil
+	}
+	err := e.link.Close()
+	e.link = nil
+	return err
+}
+
 func boolToUint8(v bool) uint8 {
 	if v {
 		return 1
@@ -3,6 +3,7 @@ package main
 import (
 	"github.com/go-gl/gl/v4.1-core/gl"
 	"github.com/go-gl/glfw/v3.3/glfw"
+	"github.com/ruiqimao/go-gb-emu/gb"
 	"github.com/ruiqimao/go-gfx/gfx"
 )
 
@@ -21,14 +22,18 @@ type Display struct {
 	quad    *gfx.Vao       // Quad shape.
 	texture *gfx.Texture2D // Display texture.
 
+	// Palette for DMG frames.
+	palette gb.Palette
+
 	// Input event channel.
 	I chan Input
 }
 
-// Create a new Display.
-func NewDisplay() (*Display, error) {
+// Create a new Display. DMG frames are shown with the given palette.
+func NewDisplay(palette gb.Palette) (*Display, error) {
 	d := &Display{
-		I: make(chan Input, 16),
+		palette: palette,
+		I:       make(chan Input, 16),
 	}
 
 	// Initialize the window.
@@ -52,17 +57,12 @@ func NewDisplay() (*Display, error) {
 }
 
 // Draw a frame.
-func (d *Display) Draw(frame []uint8) {
+func (d *Display) Draw(frame gb.Frame) {
+	img := frame.RGBA(d.palette)
 	gfx.Do(func() {
-		// If the frame is nil, turn off the display.
-		if frame == nil {
-			// TODO.
-			return
-		}
-
 		// Update the texture.
 		if d.texture != nil {
-			d.texture.SetData(gl.Ptr(frame), gl.RED, gl.UNSIGNED_BYTE)
+			d.texture.SetData(gl.Ptr(img.Pix), gl.RGBA, gl.UNSIGNED_BYTE)
 		}
 	})
 }
@@ -90,7 +90,7 @@ func (d *Display) init() error {
 	d.quad = gfx.NewVao(vbo, gl.TRIANGLE_STRIP)
 
 	// Make the texture.
-	d.texture = gfx.NewTexture2D(nil, FrameWidth, FrameHeight, gl.RED, gl.UNSIGNED_BYTE)
+	d.texture = gfx.NewTexture2D(nil, FrameWidth, FrameHeight, gl.RGBA, gl.UNSIGNED_BYTE)
 	d.texture.Bind()
 	d.texture.SetParam(gl.TEXTURE_MIN_FILTER, gl.NEAREST)
 	d.texture.SetParam(gl.TEXTURE_MAG_FILTER, gl.NEAREST)
@@ -106,9 +106,9 @@ func (d *Display) run() {
 	for !d.window.ShouldClose() {
 		gfx.Do(func() {
 			gl.Clear(gl.COLOR_BUFFER_BIT | gl.DEPTH_BUFFER_BIT)
-			d.program.Bind()
 
 			// Draw the display.
+			d.program.Bind()
 			d.program.SetTexture2D("tex", d.texture)
 			d.quad.Bind()
 			d.quad.Draw()
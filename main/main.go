@@ -1,38 +1,75 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"io/ioutil"
 	"log"
 	"os"
+	"time"
 
 	"github.com/ruiqimao/go-gb-emu/cart"
 	"github.com/ruiqimao/go-gb-emu/gb"
+	"github.com/ruiqimao/go-gb-emu/gb/serial"
 	"github.com/ruiqimao/go-gfx/gfx"
 )
 
+const (
+	SaveInterval = 10 * time.Second // How often battery saves are flushed.
+)
+
 type Emulator struct {
-	gb *gb.GameBoy
-	dp *Display
+	gb   *gb.GameBoy
+	dp   *Display
+	cart *cart.Cartridge
+
+	// Link cable to another emulator instance.
+	link *serial.Link
 }
 
 func main() {
-	if len(os.Args) != 3 {
-		fmt.Fprintf(os.Stderr, "Usage: %v <boot.bin> <rom.gb>\n", os.Args[0])
+	paletteFlag := flag.String("palette", "grey", "DMG palette: grey, green, pocket, light, or 4 colors as RRGGBB,RRGGBB,RRGGBB,RRGGBB")
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %v [-palette palette] [boot.bin] <rom.gb>\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	args := flag.Args()
+	if len(args) != 1 && len(args) != 2 {
+		flag.Usage()
 		os.Exit(1)
 	}
+	palette, err := gb.ParsePalette(*paletteFlag)
+	if err != nil {
+		log.Fatal(err)
+	}
 
-	// Create and run the emulator.
-	_, err := NewEmulator(os.Args[1], os.Args[2])
+	// Create and run the emulator. Without a boot ROM, the boot ROM is skipped.
+	bootPath := ""
+	cartPath := args[len(args)-1]
+	if len(args) == 2 {
+		bootPath = args[0]
+	}
+	e, err := NewEmulator(bootPath, cartPath, palette)
 	if err != nil {
 		log.Fatal(err)
 	}
 
 	// Run the graphics loop. This must be done on the main thread.
 	gfx.Run()
+
+	// Finish any audio recording and write out the battery save before exiting.
+	err = e.gb.StopRecording()
+	if err != nil {
+		log.Fatal(err)
+	}
+	err = e.cart.Close()
+	if err != nil {
+		log.Fatal(err)
+	}
 }
 
-func NewEmulator(bootPath string, cartPath string) (*Emulator, error) {
+func NewEmulator(bootPath string, cartPath string, palette gb.Palette) (*Emulator, error) {
 	e := &Emulator{}
 	var err error
 
@@ -43,31 +80,53 @@ func NewEmulator(bootPath string, cartPath string) (*Emulator, error) {
 	}
 
 	// Create a display for the gameboy.
-	e.dp, err = NewDisplay()
+	e.dp, err = NewDisplay(palette)
 	if err != nil {
 		return nil, err
 	}
 
-	// Load the boot ROM.
-	boot, err := ioutil.ReadFile(bootPath)
+	// Load the cartridge.
+	cartData, err := ioutil.ReadFile(cartPath)
 	if err != nil {
 		return nil, err
 	}
-	err = e.gb.LoadBootRom(boot)
+	e.cart, err = cart.NewCartridge(cartData)
 	if err != nil {
 		return nil, err
 	}
+	for _, warning := range e.cart.Warnings() {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", warning)
+	}
 
-	// Load the cartridge.
-	cartData, err := ioutil.ReadFile(cartPath)
+	// Load the battery save and keep it up to date.
+	err = e.cart.AttachSaveFile(cart.SavePath(cartPath))
 	if err != nil {
 		return nil, err
 	}
-	cart, err := cart.NewCartridge(cartData)
-	if err != nil {
-		return nil, err
+	go e.saveLoop(e.cart.StartAutoFlush(SaveInterval))
+
+	e.gb.LoadCartridge(e.cart)
+
+	// Load the boot ROM, or skip it as the model the cartridge is made for.
+	if bootPath != "" {
+		boot, err := ioutil.ReadFile(bootPath)
+		if err != nil {
+			return nil, err
+		}
+		err = e.gb.LoadBootRom(boot)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		model := gb.ModelDMG
+		if e.cart.Header().CGB() {
+			model = gb.ModelCGB
+		}
+		err = e.gb.SkipBoot(model)
+		if err != nil {
+			return nil, err
+		}
 	}
-	e.gb.LoadCartridge(cart)
 
 	// Run the main loop.
 	go e.mainLoop()
@@ -94,6 +153,17 @@ func (e *Emulator) mainLoop() {
 		case event := <-e.dp.I:
 			e.gb.Input(event)
 
+		// Report faults. The gameboy pauses itself so that it can be inspected in the debugger.
+		case fault := <-e.gb.Faults:
+			fmt.Fprintf(os.Stderr, "\n%v\n> ", fault)
+
 		}
 	}
 }
+
+func (e *Emulator) saveLoop(errs <-chan error) {
+	// Report save errors without stopping the emulator.
+	for err := range errs {
+		fmt.Fprintf(os.Stderr, "Failed to write save: %v\n", err)
+	}
+}
@@ -26,8 +26,7 @@ void main() {
 	// Get the pixel in the texture from the fragment position.
 	float x = (pos.x + 1.0) * 0.5;
 	float y = (1.0 - pos.y) * 0.5;
-	float p = 1.0 - texture(tex, vec2(x, y)).r * 85.0;
 
-	fragColor = vec4(p, p, p, 1.0);
+	fragColor = vec4(texture(tex, vec2(x, y)).rgb, 1.0);
 }
 `